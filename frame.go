@@ -0,0 +1,363 @@
+package gofi
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"net"
+)
+
+// A FrameType identifies the major class of an IEEE 802.11 MAC frame.
+type FrameType uint8
+
+// The three frame types defined by 802.11, plus the newer Extension class.
+const (
+	FrameTypeManagement FrameType = 0
+	FrameTypeControl    FrameType = 1
+	FrameTypeData       FrameType = 2
+	FrameTypeExtension  FrameType = 3
+)
+
+// A FrameSubtype identifies the specific kind of frame within a FrameType.
+// Subtype values are only unique within their own FrameType.
+type FrameSubtype uint8
+
+// Management subtypes.
+const (
+	SubtypeAssocRequest     FrameSubtype = 0x0
+	SubtypeAssocResponse    FrameSubtype = 0x1
+	SubtypeReassocRequest   FrameSubtype = 0x2
+	SubtypeReassocResponse  FrameSubtype = 0x3
+	SubtypeProbeRequest     FrameSubtype = 0x4
+	SubtypeProbeResponse    FrameSubtype = 0x5
+	SubtypeBeacon           FrameSubtype = 0x8
+	SubtypeATIM             FrameSubtype = 0x9
+	SubtypeDisassociation   FrameSubtype = 0xa
+	SubtypeAuthentication   FrameSubtype = 0xb
+	SubtypeDeauthentication FrameSubtype = 0xc
+	SubtypeAction           FrameSubtype = 0xd
+)
+
+// Control subtypes.
+const (
+	SubtypeBlockAckRequest FrameSubtype = 0x8
+	SubtypeBlockAck        FrameSubtype = 0x9
+	SubtypePSPoll          FrameSubtype = 0xa
+	SubtypeRTS             FrameSubtype = 0xb
+	SubtypeCTS             FrameSubtype = 0xc
+	SubtypeACK             FrameSubtype = 0xd
+	SubtypeCFEnd           FrameSubtype = 0xe
+	SubtypeCFEndCFAck      FrameSubtype = 0xf
+)
+
+// Data subtypes.
+const (
+	SubtypeData        FrameSubtype = 0x0
+	SubtypeNullData    FrameSubtype = 0x4
+	SubtypeQoSData     FrameSubtype = 0x8
+	SubtypeQoSNullData FrameSubtype = 0xc
+)
+
+// A FrameControl is the first two bytes of every 802.11 MAC frame.
+type FrameControl struct {
+	Version uint8
+	Type    FrameType
+	Subtype FrameSubtype
+
+	ToDS      bool
+	FromDS    bool
+	MoreFrag  bool
+	Retry     bool
+	PowerMgmt bool
+	MoreData  bool
+	Protected bool
+
+	// Order is the "Order" bit. On QoS data and management frames it
+	// indicates that an HT Control field follows the address/sequence
+	// fields (the "+HTC" variant).
+	Order bool
+}
+
+func parseFrameControl(v uint16) FrameControl {
+	return FrameControl{
+		Version:   uint8(v & 0x3),
+		Type:      FrameType((v >> 2) & 0x3),
+		Subtype:   FrameSubtype((v >> 4) & 0xf),
+		ToDS:      v&(1<<8) != 0,
+		FromDS:    v&(1<<9) != 0,
+		MoreFrag:  v&(1<<10) != 0,
+		Retry:     v&(1<<11) != 0,
+		PowerMgmt: v&(1<<12) != 0,
+		MoreData:  v&(1<<13) != 0,
+		Protected: v&(1<<14) != 0,
+		Order:     v&(1<<15) != 0,
+	}
+}
+
+func (f FrameControl) encode() uint16 {
+	v := uint16(f.Version&0x3) | (uint16(f.Type&0x3) << 2) | (uint16(f.Subtype&0xf) << 4)
+	setBit := func(cond bool, bit uint) {
+		if cond {
+			v |= 1 << bit
+		}
+	}
+	setBit(f.ToDS, 8)
+	setBit(f.FromDS, 9)
+	setBit(f.MoreFrag, 10)
+	setBit(f.Retry, 11)
+	setBit(f.PowerMgmt, 12)
+	setBit(f.MoreData, 13)
+	setBit(f.Protected, 14)
+	setBit(f.Order, 15)
+	return v
+}
+
+// hasHTControl reports whether an +HTC field is present, per the Order bit
+// semantics on QoS data and management frames.
+func (f FrameControl) hasHTControl() bool {
+	if !f.Order {
+		return false
+	}
+	switch f.Type {
+	case FrameTypeData:
+		return f.Subtype&0x8 != 0
+	case FrameTypeManagement:
+		return true
+	default:
+		return false
+	}
+}
+
+// A SequenceControl carries a frame's fragment and sequence numbers.
+type SequenceControl struct {
+	Fragment uint8
+	Sequence uint16
+}
+
+func parseSequenceControl(v uint16) SequenceControl {
+	return SequenceControl{
+		Fragment: uint8(v & 0xf),
+		Sequence: v >> 4,
+	}
+}
+
+func (s SequenceControl) encode() uint16 {
+	return uint16(s.Fragment&0xf) | (s.Sequence << 4)
+}
+
+// A QoSControl is the QoS Control field present in QoS data frames.
+type QoSControl uint16
+
+// TID returns the traffic identifier carried in the QoS Control field.
+func (q QoSControl) TID() uint8 {
+	return uint8(q & 0xf)
+}
+
+// ErrFrameTooShort is returned when a byte slice is too small to contain
+// the fields its frame control indicates it should have.
+var ErrFrameTooShort = errors.New("gofi: frame too short")
+
+// ErrInvalidFCS is returned by ParseFrame when the trailing FCS does not
+// match the computed CRC-32 of the preceding bytes.
+var ErrInvalidFCS = errors.New("gofi: invalid FCS")
+
+// A Frame is a parsed IEEE 802.11 MAC frame.
+type Frame struct {
+	FrameControl    FrameControl
+	DurationID      uint16
+	Addr1           net.HardwareAddr
+	Addr2           net.HardwareAddr
+	Addr3           net.HardwareAddr
+	Addr4           net.HardwareAddr
+	SequenceControl SequenceControl
+
+	// QoSControl is non-nil for QoS data frames.
+	QoSControl *QoSControl
+
+	// HTControl is non-nil for +HTC frames.
+	HTControl *uint32
+
+	// Body is the frame body (management fixed fields and IEs, the LLC
+	// payload for data frames, or empty for most control frames).
+	Body []byte
+
+	// FCS is non-nil if the frame was parsed with a trailing frame check
+	// sequence, in which case it has already been validated.
+	FCS *uint32
+}
+
+// ParseFrame decodes an IEEE 802.11 MAC frame.
+//
+// If hasFCS is true, the last 4 bytes of data are treated as a trailing
+// frame check sequence and validated against the rest of the frame.
+func ParseFrame(data []byte, hasFCS bool) (*Frame, error) {
+	var fcs *uint32
+	if hasFCS {
+		if len(data) < 4 {
+			return nil, ErrFrameTooShort
+		}
+		want := binary.LittleEndian.Uint32(data[len(data)-4:])
+		got := crc32.ChecksumIEEE(data[:len(data)-4])
+		if want != got {
+			return nil, ErrInvalidFCS
+		}
+		fcs = &want
+		data = data[:len(data)-4]
+	}
+
+	if len(data) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	fc := parseFrameControl(binary.LittleEndian.Uint16(data))
+	f := &Frame{FrameControl: fc, FCS: fcs}
+
+	off := 2
+
+	// Only ACK and CTS carry a single address; every other control subtype
+	// (RTS, Block Ack/Request, PS-Poll, CF-End, CF-End+CF-Ack) carries two.
+	need := func(n int) error {
+		if len(data) < off+n {
+			return ErrFrameTooShort
+		}
+		return nil
+	}
+
+	if err := need(2); err != nil {
+		return nil, err
+	}
+	f.DurationID = binary.LittleEndian.Uint16(data[off:])
+	off += 2
+
+	if err := need(6); err != nil {
+		return nil, err
+	}
+	f.Addr1 = net.HardwareAddr(data[off : off+6])
+	off += 6
+
+	if fc.Type == FrameTypeControl && fc.Subtype != SubtypeRTS && fc.Subtype != SubtypeBlockAck &&
+		fc.Subtype != SubtypeBlockAckRequest && fc.Subtype != SubtypePSPoll && fc.Subtype != SubtypeCFEnd &&
+		fc.Subtype != SubtypeCFEndCFAck {
+		// ACK and CTS stop after Addr1.
+		f.Body = append([]byte{}, data[off:]...)
+		return f, nil
+	}
+
+	if err := need(6); err != nil {
+		return nil, err
+	}
+	f.Addr2 = net.HardwareAddr(data[off : off+6])
+	off += 6
+
+	if fc.Type == FrameTypeControl {
+		f.Body = append([]byte{}, data[off:]...)
+		return f, nil
+	}
+
+	if err := need(6); err != nil {
+		return nil, err
+	}
+	f.Addr3 = net.HardwareAddr(data[off : off+6])
+	off += 6
+
+	if err := need(2); err != nil {
+		return nil, err
+	}
+	f.SequenceControl = parseSequenceControl(binary.LittleEndian.Uint16(data[off:]))
+	off += 2
+
+	if fc.ToDS && fc.FromDS {
+		if err := need(6); err != nil {
+			return nil, err
+		}
+		f.Addr4 = net.HardwareAddr(data[off : off+6])
+		off += 6
+	}
+
+	if fc.Type == FrameTypeData && fc.Subtype&0x8 != 0 {
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		qos := QoSControl(binary.LittleEndian.Uint16(data[off:]))
+		f.QoSControl = &qos
+		off += 2
+	}
+
+	if fc.hasHTControl() {
+		if err := need(4); err != nil {
+			return nil, err
+		}
+		htc := binary.LittleEndian.Uint32(data[off:])
+		f.HTControl = &htc
+		off += 4
+	}
+
+	f.Body = append([]byte{}, data[off:]...)
+	return f, nil
+}
+
+// Encode reconstructs the raw bytes of the frame, including a freshly
+// computed FCS trailer if the frame was parsed with one (or FCS is
+// otherwise non-nil).
+func (f *Frame) Encode() []byte {
+	buf := make([]byte, 2, 2+2+6*4+2+2+4+len(f.Body)+4)
+	binary.LittleEndian.PutUint16(buf, f.FrameControl.encode())
+
+	putUint16 := func(v uint16) {
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], v)
+		buf = append(buf, b[:]...)
+	}
+
+	putUint16(f.DurationID)
+	buf = append(buf, addrBytes(f.Addr1)...)
+
+	if f.FrameControl.Type == FrameTypeControl && f.FrameControl.Subtype != SubtypeRTS &&
+		f.FrameControl.Subtype != SubtypeBlockAck && f.FrameControl.Subtype != SubtypeBlockAckRequest &&
+		f.FrameControl.Subtype != SubtypePSPoll && f.FrameControl.Subtype != SubtypeCFEnd &&
+		f.FrameControl.Subtype != SubtypeCFEndCFAck {
+		buf = append(buf, f.Body...)
+		return f.appendFCS(buf)
+	}
+
+	buf = append(buf, addrBytes(f.Addr2)...)
+
+	if f.FrameControl.Type == FrameTypeControl {
+		buf = append(buf, f.Body...)
+		return f.appendFCS(buf)
+	}
+
+	buf = append(buf, addrBytes(f.Addr3)...)
+	putUint16(f.SequenceControl.encode())
+
+	if f.FrameControl.ToDS && f.FrameControl.FromDS {
+		buf = append(buf, addrBytes(f.Addr4)...)
+	}
+
+	if f.QoSControl != nil {
+		putUint16(uint16(*f.QoSControl))
+	}
+
+	if f.HTControl != nil {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], *f.HTControl)
+		buf = append(buf, b[:]...)
+	}
+
+	buf = append(buf, f.Body...)
+	return f.appendFCS(buf)
+}
+
+func (f *Frame) appendFCS(buf []byte) []byte {
+	if f.FCS == nil {
+		return buf
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], crc32.ChecksumIEEE(buf))
+	return append(buf, b[:]...)
+}
+
+func addrBytes(a net.HardwareAddr) []byte {
+	b := make([]byte, 6)
+	copy(b, a)
+	return b
+}
@@ -0,0 +1,116 @@
+package gofi
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func mustAddr(s string) net.HardwareAddr {
+	a, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func TestParseFramePSPollHasAddr2(t *testing.T) {
+	bssid := mustAddr("00:11:22:33:44:55")
+	ta := mustAddr("aa:bb:cc:dd:ee:ff")
+
+	fc := FrameControl{Type: FrameTypeControl, Subtype: SubtypePSPoll}
+	raw := make([]byte, 16)
+	raw[0] = byte(fc.encode())
+	raw[1] = byte(fc.encode() >> 8)
+	copy(raw[4:10], bssid)
+	copy(raw[10:16], ta)
+
+	f, err := ParseFrame(raw, false)
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	if f.Addr1.String() != bssid.String() {
+		t.Fatalf("Addr1 = %v, want %v", f.Addr1, bssid)
+	}
+	if f.Addr2 == nil || f.Addr2.String() != ta.String() {
+		t.Fatalf("Addr2 = %v, want %v", f.Addr2, ta)
+	}
+	if len(f.Body) != 0 {
+		t.Fatalf("Body = %v, want empty (TA must not leak into Body)", f.Body)
+	}
+}
+
+func TestParseEncodeRoundTripControlFrames(t *testing.T) {
+	addr1 := mustAddr("00:11:22:33:44:55")
+	addr2 := mustAddr("aa:bb:cc:dd:ee:ff")
+
+	cases := []struct {
+		name    string
+		subtype FrameSubtype
+	}{
+		{"ACK", SubtypeACK},
+		{"CTS", SubtypeCTS},
+		{"RTS", SubtypeRTS},
+		{"PSPoll", SubtypePSPoll},
+		{"CFEnd", SubtypeCFEnd},
+		{"CFEndCFAck", SubtypeCFEndCFAck},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frame{
+				FrameControl: FrameControl{Type: FrameTypeControl, Subtype: c.subtype},
+				DurationID:   0x1234,
+				Addr1:        addr1,
+				Addr2:        addr2,
+			}
+			raw := f.Encode()
+
+			got, err := ParseFrame(raw, false)
+			if err != nil {
+				t.Fatalf("ParseFrame: %v", err)
+			}
+			if got.Addr1.String() != addr1.String() {
+				t.Fatalf("Addr1 = %v, want %v", got.Addr1, addr1)
+			}
+			switch c.subtype {
+			case SubtypeACK, SubtypeCTS:
+				if got.Addr2 != nil {
+					t.Fatalf("Addr2 = %v, want nil", got.Addr2)
+				}
+			default:
+				if got.Addr2 == nil || got.Addr2.String() != addr2.String() {
+					t.Fatalf("Addr2 = %v, want %v", got.Addr2, addr2)
+				}
+			}
+
+			roundTrip := got.Encode()
+			if !bytes.Equal(raw, roundTrip) {
+				t.Fatalf("re-encode mismatch:\n  got  %x\n  want %x", roundTrip, raw)
+			}
+		})
+	}
+}
+
+func TestParseFrameWithFCS(t *testing.T) {
+	f := &Frame{
+		FrameControl: FrameControl{Type: FrameTypeControl, Subtype: SubtypeACK},
+		DurationID:   0,
+		Addr1:        mustAddr("00:11:22:33:44:55"),
+		FCS:          new(uint32),
+	}
+	raw := f.Encode()
+
+	got, err := ParseFrame(raw, true)
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	if got.FCS == nil {
+		t.Fatalf("FCS not populated")
+	}
+
+	raw[len(raw)-1] ^= 0xff
+	if _, err := ParseFrame(raw, true); err != ErrInvalidFCS {
+		t.Fatalf("err = %v, want ErrInvalidFCS", err)
+	}
+}
@@ -49,6 +49,16 @@ func (d DataRate) String() string {
 	return fmt.Sprintf("%.1f Mb/s", mbps)
 }
 
+// TXParams configures how Send transmits a frame.
+type TXParams struct {
+	// Rate is the legacy data rate to transmit at. It is ignored if MCS
+	// is non-nil, and if both are zero the lowest supported rate is used.
+	Rate DataRate
+
+	// MCS requests a specific HT/VHT rate instead of a legacy Rate.
+	MCS *MCS
+}
+
 // A Handle facilitates raw WiFi interactions like packet injection,
 // sniffing, and channel hopping.
 type Handle interface {
@@ -69,12 +79,13 @@ type Handle interface {
 
 	// Receive reads the next packet from the device.
 	// The returned RadioInfo will be nil if the device does not
-	// support radio information.
+	// support radio information. Implementations that capture via
+	// radiotap should populate it with RadioInfoFromRadiotap so that
+	// per-frame signal, noise, rate and bad-FCS flags are available.
 	Receive() (Frame, *RadioInfo, error)
 
-	// Send sends a packet over the device.
-	// If the given DataRate is 0, the lowest supported rate is used.
-	Send(Frame, DataRate) error
+	// Send sends a packet over the device using the given TXParams.
+	Send(Frame, TXParams) error
 
 	// Close closes the handle.
 	// You should always close a Handle once you are done with it.
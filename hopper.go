@@ -0,0 +1,282 @@
+package gofi
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A HopEvent reports a channel change made by a Hopper.
+type HopEvent struct {
+	Channel Channel
+	Time    time.Time
+}
+
+// A HopPolicy decides which channel a Hopper should move to next and how
+// long to dwell there. Observe is called after every frame the Hopper
+// receives, so policies that adapt to traffic (like FollowActivityPolicy)
+// can adjust their dwell times; policies that don't care can embed
+// NoObserve to satisfy the interface.
+type HopPolicy interface {
+	// Next returns the next channel to hop to and how long to dwell on it.
+	Next() (Channel, time.Duration)
+
+	// Observe reports a frame received while camped on ch.
+	Observe(ch Channel, f Frame, info *RadioInfo)
+}
+
+// NoObserve is a HopPolicy.Observe implementation that ignores everything.
+// Embed it in policies that don't adapt to observed traffic.
+type NoObserve struct{}
+
+// Observe does nothing.
+func (NoObserve) Observe(Channel, Frame, *RadioInfo) {}
+
+// A RoundRobinPolicy visits a fixed, ordered list of channels with a
+// constant dwell time. Passing a Handle's SupportedChannels() gives plain
+// round-robin hopping; passing a user-curated list hops only that subset,
+// in that order.
+type RoundRobinPolicy struct {
+	NoObserve
+
+	Channels []Channel
+	Dwell    time.Duration
+
+	next int
+}
+
+// NewRoundRobinPolicy creates a RoundRobinPolicy over channels, dwelling
+// on each for the given duration.
+func NewRoundRobinPolicy(channels []Channel, dwell time.Duration) *RoundRobinPolicy {
+	return &RoundRobinPolicy{Channels: channels, Dwell: dwell}
+}
+
+// Next returns the next channel in the list, wrapping around at the end.
+func (p *RoundRobinPolicy) Next() (Channel, time.Duration) {
+	if len(p.Channels) == 0 {
+		return Channel{}, p.Dwell
+	}
+	ch := p.Channels[p.next%len(p.Channels)]
+	p.next++
+	return ch, p.Dwell
+}
+
+// A WeightedPolicy visits channels in a fixed order, dwelling on each for
+// its own configured duration, so busier channels (e.g. 2.4 GHz 1/6/11)
+// can be given more time than the rest.
+type WeightedPolicy struct {
+	NoObserve
+
+	Order   []Channel
+	Dwell   map[Channel]time.Duration
+	Default time.Duration
+
+	next int
+}
+
+// NewWeightedPolicy creates a WeightedPolicy that visits order in
+// sequence, dwelling on each channel for dwell[channel], or def if the
+// channel has no entry in dwell.
+func NewWeightedPolicy(order []Channel, dwell map[Channel]time.Duration, def time.Duration) *WeightedPolicy {
+	return &WeightedPolicy{Order: order, Dwell: dwell, Default: def}
+}
+
+// Next returns the next channel in Order and its configured dwell time.
+func (p *WeightedPolicy) Next() (Channel, time.Duration) {
+	if len(p.Order) == 0 {
+		return Channel{}, p.Default
+	}
+	ch := p.Order[p.next%len(p.Order)]
+	p.next++
+	dwell, ok := p.Dwell[ch]
+	if !ok {
+		dwell = p.Default
+	}
+	return ch, dwell
+}
+
+// A FollowActivityPolicy round-robins over a channel list like
+// RoundRobinPolicy, but lengthens dwell time on channels where recent
+// frames looked like beacons or data, on the theory that a channel worth
+// hopping to once is worth staying on a little longer.
+type FollowActivityPolicy struct {
+	Channels  []Channel
+	BaseDwell time.Duration
+	MaxDwell  time.Duration
+	PerFrame  time.Duration
+
+	mu       sync.Mutex
+	activity map[Channel]time.Duration
+	next     int
+}
+
+// NewFollowActivityPolicy creates a FollowActivityPolicy over channels.
+// Each channel starts at baseDwell; every beacon or data frame observed
+// on it adds perFrame, capped at maxDwell. Each time Next picks a channel,
+// its accumulated activity is used for that visit and reset to zero, so
+// frames seen during one visit lengthen the following one.
+func NewFollowActivityPolicy(channels []Channel, baseDwell, maxDwell, perFrame time.Duration) *FollowActivityPolicy {
+	return &FollowActivityPolicy{
+		Channels:  channels,
+		BaseDwell: baseDwell,
+		MaxDwell:  maxDwell,
+		PerFrame:  perFrame,
+		activity:  map[Channel]time.Duration{},
+	}
+}
+
+// Next returns the next channel and its activity-adjusted dwell time,
+// then resets that channel's accumulated activity for the following lap.
+func (p *FollowActivityPolicy) Next() (Channel, time.Duration) {
+	if len(p.Channels) == 0 {
+		return Channel{}, p.BaseDwell
+	}
+	ch := p.Channels[p.next%len(p.Channels)]
+	p.next++
+
+	p.mu.Lock()
+	dwell := p.BaseDwell + p.activity[ch]
+	if dwell > p.MaxDwell {
+		dwell = p.MaxDwell
+	}
+	p.activity[ch] = 0
+	p.mu.Unlock()
+
+	return ch, dwell
+}
+
+// Observe lengthens ch's next dwell time if f looks like a beacon or data
+// frame.
+func (p *FollowActivityPolicy) Observe(ch Channel, f Frame, info *RadioInfo) {
+	interesting := f.FrameControl.Type == FrameTypeData ||
+		(f.FrameControl.Type == FrameTypeManagement && f.FrameControl.Subtype == SubtypeBeacon)
+	if !interesting {
+		return
+	}
+	p.mu.Lock()
+	p.activity[ch] += p.PerFrame
+	p.mu.Unlock()
+}
+
+// A Hopper drives a Handle's SetChannel according to a HopPolicy, running
+// the hop timer on its own goroutine. It serializes hops against Receive
+// calls made through the Hopper (rather than directly against the wrapped
+// Handle) so a hop never lands mid-frame.
+//
+// Because Receive blocks until a frame or error arrives, a policy's dwell
+// time is a lower bound on real hardware: a hop is delayed, never
+// preempted, by an in-flight Receive.
+type Hopper struct {
+	handle Handle
+	policy HopPolicy
+	events chan HopEvent
+
+	// mu serializes SetChannel against Receive. It is held across an
+	// entire Receive call, which can block indefinitely, so it is only
+	// ever acquired with TryLock outside of Receive itself.
+	mu sync.Mutex
+
+	paused atomic.Bool
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewHopper creates a Hopper that drives handle according to policy and
+// starts hopping immediately.
+func NewHopper(handle Handle, policy HopPolicy) *Hopper {
+	h := &Hopper{
+		handle: handle,
+		policy: policy,
+		events: make(chan HopEvent, 1),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go h.loop()
+	return h
+}
+
+// Events returns a channel that receives an event each time the Hopper
+// changes channel. The channel buffers one event; an event is dropped if
+// the previous one has not been received yet. It is never closed by the
+// Hopper.
+func (h *Hopper) Events() <-chan HopEvent {
+	return h.events
+}
+
+func (h *Hopper) loop() {
+	defer close(h.doneCh)
+	for {
+		if h.paused.Load() {
+			select {
+			case <-h.stopCh:
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+			continue
+		}
+
+		ch, dwell := h.policy.Next()
+
+		// Use TryLock rather than Lock: a Receive call can block
+		// indefinitely waiting for a frame, and it holds h.mu while it
+		// does. Blocking here too would let a stalled Receive wedge
+		// Close() forever, so retry the same hop every few milliseconds,
+		// checking for Close between attempts.
+		for !h.mu.TryLock() {
+			select {
+			case <-h.stopCh:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		err := h.handle.SetChannel(ch)
+		h.mu.Unlock()
+		if err == nil {
+			select {
+			case h.events <- HopEvent{Channel: ch, Time: time.Now()}:
+			default:
+			}
+		}
+
+		select {
+		case <-h.stopCh:
+			return
+		case <-time.After(dwell):
+		}
+	}
+}
+
+// Receive reads the next frame from the wrapped Handle, reports it to the
+// policy, and blocks any pending hop until it returns.
+func (h *Hopper) Receive() (Frame, *RadioInfo, error) {
+	h.mu.Lock()
+	f, info, err := h.handle.Receive()
+	ch := h.handle.Channel()
+	h.mu.Unlock()
+
+	if err == nil {
+		h.policy.Observe(ch, f, info)
+	}
+	return f, info, err
+}
+
+// Pause stops hopping and pins the current channel, for a caller that
+// needs to complete a targeted exchange without interruption.
+func (h *Hopper) Pause() {
+	h.paused.Store(true)
+}
+
+// Resume resumes hopping after Pause.
+func (h *Hopper) Resume() {
+	h.paused.Store(false)
+}
+
+// Close stops the hopping goroutine. It does not close the wrapped Handle.
+func (h *Hopper) Close() {
+	h.stopOnce.Do(func() {
+		close(h.stopCh)
+		<-h.doneCh
+	})
+}
@@ -0,0 +1,95 @@
+package gofi
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeHopHandle is a minimal Handle whose Receive blocks for recvDelay
+// before returning a frame. SetChannel writes ch and Channel (which
+// Hopper.Receive calls right after Receive) reads it, without any locking
+// of their own, so a data race between them
+// (e.g. `go test -race` reporting one) means a hop landed mid-frame,
+// which is exactly the property Hopper promises never to allow.
+type fakeHopHandle struct {
+	ch        Channel
+	recvDelay time.Duration
+	calls     int
+}
+
+var errFakeClosed = errors.New("fakeHopHandle: closed")
+
+func (f *fakeHopHandle) SupportedRates() []DataRate   { return nil }
+func (f *fakeHopHandle) SupportedChannels() []Channel { return nil }
+func (f *fakeHopHandle) Channel() Channel             { return f.ch }
+func (f *fakeHopHandle) SetChannel(c Channel) error   { f.ch = c; return nil }
+func (f *fakeHopHandle) Send(Frame, TXParams) error   { return nil }
+func (f *fakeHopHandle) Close()                       {}
+
+func (f *fakeHopHandle) Receive() (Frame, *RadioInfo, error) {
+	f.calls++
+	if f.calls > 200 {
+		return Frame{}, nil, errFakeClosed
+	}
+	time.Sleep(f.recvDelay)
+	return Frame{FrameControl: FrameControl{Type: FrameTypeControl, Subtype: SubtypeACK}}, nil, nil
+}
+
+// TestHopperSerializesSetChannelAgainstReceive drives a Hopper with a dwell
+// time shorter than each simulated Receive, so the loop goroutine is
+// constantly racing to hop while Receive is in flight. Run with `go test
+// -race` to verify Hopper.mu actually keeps SetChannel and Receive from
+// touching the handle concurrently.
+func TestHopperSerializesSetChannelAgainstReceive(t *testing.T) {
+	fh := &fakeHopHandle{recvDelay: time.Millisecond}
+	policy := NewRoundRobinPolicy([]Channel{{Number: 1}, {Number: 6}, {Number: 11}}, 100*time.Microsecond)
+	h := NewHopper(fh, policy)
+	defer h.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := h.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Receive loop to finish")
+	}
+}
+
+func TestHopperPauseStopsHopping(t *testing.T) {
+	fh := &fakeHopHandle{recvDelay: time.Millisecond}
+	policy := NewRoundRobinPolicy([]Channel{{Number: 1}, {Number: 6}}, time.Millisecond)
+	h := NewHopper(fh, policy)
+	defer h.Close()
+
+	var count int32
+	go func() {
+		for range h.Events() {
+			atomic.AddInt32(&count, 1)
+		}
+	}()
+
+	// Let it hop a few times, then pause. One more hop may already be
+	// in flight when Pause takes effect, so allow a small grace window
+	// before asserting the count has stopped growing.
+	time.Sleep(20 * time.Millisecond)
+	h.Pause()
+	time.Sleep(5 * time.Millisecond)
+	afterPause := atomic.LoadInt32(&count)
+
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&count); got != afterPause {
+		t.Fatalf("hop count grew from %d to %d while paused", afterPause, got)
+	}
+
+	h.Resume()
+}
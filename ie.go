@@ -0,0 +1,328 @@
+package gofi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Information Element IDs, as assigned by the IEEE 802.11 standard.
+const (
+	IESSID              uint8 = 0
+	IESupportedRates    uint8 = 1
+	IEDSParameterSet    uint8 = 3
+	IETIM               uint8 = 5
+	IECountry           uint8 = 7
+	IEBSSLoad           uint8 = 11
+	IERSN               uint8 = 48
+	IEExtSupportedRates uint8 = 50
+	IEHTCapabilities    uint8 = 45
+	IEHTOperation       uint8 = 61
+	IEVHTCapabilities   uint8 = 191
+	IEVHTOperation      uint8 = 192
+	IEVendorSpecific    uint8 = 221
+)
+
+// SSID decodes an SSID Information Element. A zero-length or all-null SSID
+// (used by APs that hide their network name) is reported as the empty
+// string. Non-printable bytes are escaped so the result is always safe to
+// print or log.
+func (ie IE) SSID() string {
+	if ie.ID != IESSID {
+		return ""
+	}
+	hidden := true
+	for _, b := range ie.Data {
+		if b != 0 {
+			hidden = false
+			break
+		}
+	}
+	if hidden {
+		return ""
+	}
+	var sb strings.Builder
+	for _, b := range ie.Data {
+		if b >= 0x20 && b < 0x7f {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "\\x%02x", b)
+		}
+	}
+	return sb.String()
+}
+
+// dataRates decodes a Supported Rates or Extended Supported Rates element.
+// The high bit of each octet (marking a rate as part of the BSSBasicRateSet)
+// is masked off.
+func (ie IE) dataRates() []DataRate {
+	rates := make([]DataRate, len(ie.Data))
+	for i, b := range ie.Data {
+		rates[i] = DataRate(b &^ 0x80)
+	}
+	return rates
+}
+
+// SupportedRates decodes a Supported Rates Information Element.
+func (ie IE) SupportedRates() []DataRate {
+	if ie.ID != IESupportedRates {
+		return nil
+	}
+	return ie.dataRates()
+}
+
+// ExtSupportedRates decodes an Extended Supported Rates Information Element.
+func (ie IE) ExtSupportedRates() []DataRate {
+	if ie.ID != IEExtSupportedRates {
+		return nil
+	}
+	return ie.dataRates()
+}
+
+// Channel decodes a DS Parameter Set Information Element, returning the
+// channel number it specifies.
+func (ie IE) Channel() (int, bool) {
+	if ie.ID != IEDSParameterSet || len(ie.Data) < 1 {
+		return 0, false
+	}
+	return int(ie.Data[0]), true
+}
+
+// A BSSLoad is the decoded form of a BSS Load Information Element,
+// normalized across the standard 802.11e encoding and the older 4-byte
+// Cisco QBSS variant.
+type BSSLoad struct {
+	StationCount uint16
+
+	// ChannelUtilization is the fraction of time, in [0, 1], that the AP
+	// sensed the channel busy, per the 802.11e definition.
+	ChannelUtilization float64
+
+	// AvailableAdmissionCapacity is available admission capacity in units
+	// of 32 microseconds per second. It is always 0 for the Cisco QBSS
+	// variant, which does not carry this field.
+	AvailableAdmissionCapacity uint16
+}
+
+// BSSLoad decodes a BSS Load Information Element. It supports both the
+// standard 5-byte 802.11e encoding and the legacy 4-byte Cisco QBSS
+// encoding.
+func (ie IE) BSSLoad() (*BSSLoad, bool) {
+	if ie.ID != IEBSSLoad {
+		return nil, false
+	}
+	switch len(ie.Data) {
+	case 5:
+		return &BSSLoad{
+			StationCount:               binary.LittleEndian.Uint16(ie.Data[0:2]),
+			ChannelUtilization:         float64(ie.Data[2]) / 255.0,
+			AvailableAdmissionCapacity: binary.LittleEndian.Uint16(ie.Data[3:5]),
+		}, true
+	case 4:
+		// Cisco QBSS: station count (uint16 LE), then a single byte of
+		// channel utilization scaled to 0..100 rather than 0..255.
+		return &BSSLoad{
+			StationCount:       binary.LittleEndian.Uint16(ie.Data[0:2]),
+			ChannelUtilization: float64(ie.Data[2]) / 100.0,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// A Country holds the decoded fixed fields of a Country Information
+// Element. The per-band triplets that follow are left undecoded, since
+// their format varies between regulatory extension and subband triplets.
+type Country struct {
+	Code     string
+	Triplets []byte
+}
+
+// Country decodes a Country Information Element.
+func (ie IE) Country() (*Country, bool) {
+	if ie.ID != IECountry || len(ie.Data) < 3 {
+		return nil, false
+	}
+	return &Country{
+		Code:     string(ie.Data[0:2]),
+		Triplets: append([]byte{}, ie.Data[3:]...),
+	}, true
+}
+
+// HTCapabilities is the decoded form of an HT Capabilities Information
+// Element.
+type HTCapabilities struct {
+	Info            uint16
+	AMPDUParams     uint8
+	SupportedMCSSet []byte
+}
+
+// HTCapabilities decodes an HT Capabilities Information Element.
+func (ie IE) HTCapabilities() (*HTCapabilities, bool) {
+	if ie.ID != IEHTCapabilities || len(ie.Data) < 3 {
+		return nil, false
+	}
+	c := &HTCapabilities{
+		Info:        binary.LittleEndian.Uint16(ie.Data[0:2]),
+		AMPDUParams: ie.Data[2],
+	}
+	if len(ie.Data) > 3 {
+		c.SupportedMCSSet = append([]byte{}, ie.Data[3:]...)
+	}
+	return c, true
+}
+
+// HTOperation is the decoded form of an HT Operation Information Element.
+type HTOperation struct {
+	PrimaryChannel int
+	Info           []byte
+}
+
+// HTOperation decodes an HT Operation Information Element.
+func (ie IE) HTOperation() (*HTOperation, bool) {
+	if ie.ID != IEHTOperation || len(ie.Data) < 1 {
+		return nil, false
+	}
+	op := &HTOperation{PrimaryChannel: int(ie.Data[0])}
+	if len(ie.Data) > 1 {
+		op.Info = append([]byte{}, ie.Data[1:]...)
+	}
+	return op, true
+}
+
+// VHTCapabilities is the decoded form of a VHT Capabilities Information
+// Element.
+type VHTCapabilities struct {
+	Info            uint32
+	SupportedMCSSet []byte
+}
+
+// VHTCapabilities decodes a VHT Capabilities Information Element.
+func (ie IE) VHTCapabilities() (*VHTCapabilities, bool) {
+	if ie.ID != IEVHTCapabilities || len(ie.Data) < 4 {
+		return nil, false
+	}
+	return &VHTCapabilities{
+		Info:            binary.LittleEndian.Uint32(ie.Data[0:4]),
+		SupportedMCSSet: append([]byte{}, ie.Data[4:]...),
+	}, true
+}
+
+// VHTOperation is the decoded form of a VHT Operation Information Element.
+type VHTOperation struct {
+	ChannelWidth   uint8
+	ChannelCenter0 int
+	ChannelCenter1 int
+	BasicMCSSet    uint16
+}
+
+// VHTOperation decodes a VHT Operation Information Element.
+func (ie IE) VHTOperation() (*VHTOperation, bool) {
+	if ie.ID != IEVHTOperation || len(ie.Data) < 5 {
+		return nil, false
+	}
+	return &VHTOperation{
+		ChannelWidth:   ie.Data[0],
+		ChannelCenter0: int(ie.Data[1]),
+		ChannelCenter1: int(ie.Data[2]),
+		BasicMCSSet:    binary.LittleEndian.Uint16(ie.Data[3:5]),
+	}, true
+}
+
+// RSNCipherSuite identifies a pairwise or group cipher suite advertised in
+// an RSN Information Element, as a 4-byte OUI+type value.
+type RSNCipherSuite uint32
+
+// Well-known cipher suites under the standard 00-0F-AC OUI.
+const (
+	CipherSuiteGroupNotAllowed RSNCipherSuite = 0x000FAC00
+	CipherSuiteWEP40           RSNCipherSuite = 0x000FAC01
+	CipherSuiteTKIP            RSNCipherSuite = 0x000FAC02
+	CipherSuiteCCMP            RSNCipherSuite = 0x000FAC04
+	CipherSuiteWEP104          RSNCipherSuite = 0x000FAC05
+	CipherSuiteGCMP256         RSNCipherSuite = 0x000FAC09
+)
+
+// RSNAKMSuite identifies an authentication and key management suite
+// advertised in an RSN Information Element.
+type RSNAKMSuite uint32
+
+// Well-known AKM suites under the standard 00-0F-AC OUI.
+const (
+	AKMSuite8021X          RSNAKMSuite = 0x000FAC01
+	AKMSuitePSK            RSNAKMSuite = 0x000FAC02
+	AKMSuiteSAE            RSNAKMSuite = 0x000FAC08
+	AKMSuite8021XSuiteB192 RSNAKMSuite = 0x000FAC0C
+)
+
+// RSN is the decoded form of an RSN (WPA2/WPA3) Information Element.
+type RSN struct {
+	Version         uint16
+	GroupCipher     RSNCipherSuite
+	PairwiseCiphers []RSNCipherSuite
+	AKMSuites       []RSNAKMSuite
+	Capabilities    uint16
+}
+
+func readSuite(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// RSN decodes an RSN Information Element (ID 48). Truncated optional
+// fields are simply omitted. Fields after Capabilities (PMKID list, group
+// management cipher) are not decoded.
+func (ie IE) RSN() (*RSN, bool) {
+	if ie.ID != IERSN || len(ie.Data) < 8 {
+		return nil, false
+	}
+	d := ie.Data
+	r := &RSN{
+		Version:     binary.LittleEndian.Uint16(d[0:2]),
+		GroupCipher: RSNCipherSuite(readSuite(d[2:6])),
+	}
+	d = d[6:]
+
+	if len(d) < 2 {
+		return r, true
+	}
+	count := int(binary.LittleEndian.Uint16(d[0:2]))
+	d = d[2:]
+	for i := 0; i < count && len(d) >= 4; i++ {
+		r.PairwiseCiphers = append(r.PairwiseCiphers, RSNCipherSuite(readSuite(d[0:4])))
+		d = d[4:]
+	}
+
+	if len(d) < 2 {
+		return r, true
+	}
+	count = int(binary.LittleEndian.Uint16(d[0:2]))
+	d = d[2:]
+	for i := 0; i < count && len(d) >= 4; i++ {
+		r.AKMSuites = append(r.AKMSuites, RSNAKMSuite(readSuite(d[0:4])))
+		d = d[4:]
+	}
+
+	if len(d) >= 2 {
+		r.Capabilities = binary.LittleEndian.Uint16(d[0:2])
+	}
+	return r, true
+}
+
+// wpaOUI and wpaType identify the vendor-specific WPA (WPA1) IE, which
+// reuses the RSN element's layout after a 4-byte OUI+type prefix.
+var wpaOUI = [3]byte{0x00, 0x50, 0xf2}
+
+const wpaType = 1
+
+// WPA decodes the vendor-specific WPA (pre-RSN "WPA1") Information
+// Element, if this IE is one. It reports false for any other vendor
+// specific element.
+func (ie IE) WPA() (*RSN, bool) {
+	if ie.ID != IEVendorSpecific || len(ie.Data) < 12 {
+		return nil, false
+	}
+	if [3]byte{ie.Data[0], ie.Data[1], ie.Data[2]} != wpaOUI || ie.Data[3] != wpaType {
+		return nil, false
+	}
+	return (IE{ID: IERSN, Data: ie.Data[4:]}).RSN()
+}
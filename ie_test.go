@@ -0,0 +1,171 @@
+package gofi
+
+import "testing"
+
+func TestIESSID(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty hidden", nil, ""},
+		{"all-null hidden", []byte{0, 0, 0}, ""},
+		{"visible", []byte("home-wifi"), "home-wifi"},
+		{"non-printable escaped", []byte{'a', 0x01, 'b'}, "a\\x01b"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ie := IE{ID: IESSID, Data: c.data}
+			if got := ie.SSID(); got != c.want {
+				t.Fatalf("SSID() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIESSIDWrongID(t *testing.T) {
+	ie := IE{ID: IESupportedRates, Data: []byte("nope")}
+	if got := ie.SSID(); got != "" {
+		t.Fatalf("SSID() = %q, want empty for non-SSID element", got)
+	}
+}
+
+func TestIEBSSLoad(t *testing.T) {
+	t.Run("5-byte 802.11e", func(t *testing.T) {
+		ie := IE{ID: IEBSSLoad, Data: []byte{10, 0, 128, 0x20, 0x00}}
+		bl, ok := ie.BSSLoad()
+		if !ok {
+			t.Fatalf("BSSLoad() ok = false, want true")
+		}
+		if bl.StationCount != 10 {
+			t.Fatalf("StationCount = %d, want 10", bl.StationCount)
+		}
+		if bl.ChannelUtilization != float64(128)/255.0 {
+			t.Fatalf("ChannelUtilization = %v, want %v", bl.ChannelUtilization, float64(128)/255.0)
+		}
+		if bl.AvailableAdmissionCapacity != 0x20 {
+			t.Fatalf("AvailableAdmissionCapacity = %d, want 0x20", bl.AvailableAdmissionCapacity)
+		}
+	})
+
+	t.Run("4-byte Cisco QBSS", func(t *testing.T) {
+		ie := IE{ID: IEBSSLoad, Data: []byte{5, 0, 50, 0}}
+		bl, ok := ie.BSSLoad()
+		if !ok {
+			t.Fatalf("BSSLoad() ok = false, want true")
+		}
+		if bl.StationCount != 5 {
+			t.Fatalf("StationCount = %d, want 5", bl.StationCount)
+		}
+		if bl.ChannelUtilization != 0.5 {
+			t.Fatalf("ChannelUtilization = %v, want 0.5", bl.ChannelUtilization)
+		}
+		if bl.AvailableAdmissionCapacity != 0 {
+			t.Fatalf("AvailableAdmissionCapacity = %d, want 0 (not carried by QBSS)", bl.AvailableAdmissionCapacity)
+		}
+	})
+
+	t.Run("unrecognized length", func(t *testing.T) {
+		ie := IE{ID: IEBSSLoad, Data: []byte{1, 2, 3}}
+		if _, ok := ie.BSSLoad(); ok {
+			t.Fatalf("BSSLoad() ok = true, want false for 3-byte data")
+		}
+	})
+}
+
+func encodeRSNBody(version uint16, group RSNCipherSuite, pairwise []RSNCipherSuite, akms []RSNAKMSuite, caps uint16) []byte {
+	putSuite := func(b []byte, s uint32) {
+		b[0] = byte(s >> 24)
+		b[1] = byte(s >> 16)
+		b[2] = byte(s >> 8)
+		b[3] = byte(s)
+	}
+	var d []byte
+	d = append(d, byte(version), byte(version>>8))
+	suite := make([]byte, 4)
+	putSuite(suite, uint32(group))
+	d = append(d, suite...)
+
+	d = append(d, byte(len(pairwise)), byte(len(pairwise)>>8))
+	for _, s := range pairwise {
+		putSuite(suite, uint32(s))
+		d = append(d, suite...)
+	}
+
+	d = append(d, byte(len(akms)), byte(len(akms)>>8))
+	for _, s := range akms {
+		putSuite(suite, uint32(s))
+		d = append(d, suite...)
+	}
+
+	d = append(d, byte(caps), byte(caps>>8))
+	return d
+}
+
+func TestIERSNAKMClassification(t *testing.T) {
+	cases := []struct {
+		name string
+		akms []RSNAKMSuite
+		want Security
+	}{
+		{"PSK is WPA2", []RSNAKMSuite{AKMSuitePSK}, SecurityWPA2},
+		{"802.1X is WPA2", []RSNAKMSuite{AKMSuite8021X}, SecurityWPA2},
+		{"SAE is WPA3", []RSNAKMSuite{AKMSuiteSAE}, SecurityWPA3},
+		{"Suite-B-192 is WPA3", []RSNAKMSuite{AKMSuite8021XSuiteB192}, SecurityWPA3},
+		{"mixed PSK+SAE transition is WPA3", []RSNAKMSuite{AKMSuitePSK, AKMSuiteSAE}, SecurityWPA3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := encodeRSNBody(1, CipherSuiteCCMP, []RSNCipherSuite{CipherSuiteCCMP}, c.akms, 0)
+			ie := IE{ID: IERSN, Data: data}
+			rsn, ok := ie.RSN()
+			if !ok {
+				t.Fatalf("RSN() ok = false, want true")
+			}
+			if len(rsn.AKMSuites) != len(c.akms) {
+				t.Fatalf("AKMSuites = %v, want %v", rsn.AKMSuites, c.akms)
+			}
+			if got := securityFromRSN(rsn); got != c.want {
+				t.Fatalf("securityFromRSN() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIERSNTruncatedOptionalFields(t *testing.T) {
+	// Only version + group cipher + a zero pairwise-cipher count; no
+	// AKM list or capabilities (the element's minimum valid length is 8).
+	data := encodeRSNBody(1, CipherSuiteCCMP, nil, nil, 0)[:8]
+	ie := IE{ID: IERSN, Data: data}
+	rsn, ok := ie.RSN()
+	if !ok {
+		t.Fatalf("RSN() ok = false, want true")
+	}
+	if rsn.GroupCipher != CipherSuiteCCMP {
+		t.Fatalf("GroupCipher = %v, want CCMP", rsn.GroupCipher)
+	}
+	if len(rsn.PairwiseCiphers) != 0 || len(rsn.AKMSuites) != 0 {
+		t.Fatalf("expected no pairwise/AKM suites from truncated data, got %+v", rsn)
+	}
+}
+
+func TestIEWPA(t *testing.T) {
+	rsnBody := encodeRSNBody(1, CipherSuiteTKIP, []RSNCipherSuite{CipherSuiteTKIP}, []RSNAKMSuite{AKMSuitePSK}, 0)
+	data := append([]byte{0x00, 0x50, 0xf2, 0x01}, rsnBody...)
+	ie := IE{ID: IEVendorSpecific, Data: data}
+
+	rsn, ok := ie.WPA()
+	if !ok {
+		t.Fatalf("WPA() ok = false, want true")
+	}
+	if rsn.GroupCipher != CipherSuiteTKIP {
+		t.Fatalf("GroupCipher = %v, want TKIP", rsn.GroupCipher)
+	}
+}
+
+func TestIEWPARejectsOtherVendorElements(t *testing.T) {
+	ie := IE{ID: IEVendorSpecific, Data: append([]byte{0x00, 0x0c, 0xe7, 0x00}, make([]byte, 8)...)}
+	if _, ok := ie.WPA(); ok {
+		t.Fatalf("WPA() ok = true, want false for a non-WPA vendor element")
+	}
+}
@@ -0,0 +1,179 @@
+package gofi
+
+import "encoding/binary"
+
+// An IE is a single Information Element from a management frame body.
+type IE struct {
+	ID   uint8
+	Data []byte
+}
+
+// ParseIEs decodes a sequence of Information Elements from a management
+// frame body (or the tail of one, after any fixed fields have been
+// stripped). Malformed trailing elements are skipped rather than causing
+// an error, since APs are known to pad or truncate them.
+func ParseIEs(data []byte) []IE {
+	var ies []IE
+	for len(data) >= 2 {
+		id := data[0]
+		length := int(data[1])
+		if len(data) < 2+length {
+			break
+		}
+		ies = append(ies, IE{ID: id, Data: append([]byte{}, data[2:2+length]...)})
+		data = data[2+length:]
+	}
+	return ies
+}
+
+// CapabilityInfo is the two-byte Capability Information field carried by
+// Beacon, Probe Response, and Association frames.
+type CapabilityInfo uint16
+
+// ESS reports whether the ESS bit is set, indicating an infrastructure BSS.
+func (c CapabilityInfo) ESS() bool { return c&(1<<0) != 0 }
+
+// IBSS reports whether the IBSS bit is set, indicating an ad-hoc network.
+func (c CapabilityInfo) IBSS() bool { return c&(1<<1) != 0 }
+
+// Privacy reports whether the network requires WEP/WPA/WPA2/WPA3 authentication.
+func (c CapabilityInfo) Privacy() bool { return c&(1<<4) != 0 }
+
+// A Beacon is the decoded body of a management frame with SubtypeBeacon.
+type Beacon struct {
+	Timestamp      uint64
+	Interval       uint16
+	CapabilityInfo CapabilityInfo
+	IEs            []IE
+}
+
+// ParseBeacon decodes the fixed fields and Information Elements of a
+// Beacon frame body.
+func ParseBeacon(body []byte) (*Beacon, error) {
+	if len(body) < 12 {
+		return nil, ErrFrameTooShort
+	}
+	return &Beacon{
+		Timestamp:      binary.LittleEndian.Uint64(body[0:8]),
+		Interval:       binary.LittleEndian.Uint16(body[8:10]),
+		CapabilityInfo: CapabilityInfo(binary.LittleEndian.Uint16(body[10:12])),
+		IEs:            ParseIEs(body[12:]),
+	}, nil
+}
+
+// A ProbeRequest is the decoded body of a management frame with
+// SubtypeProbeRequest. It has no fixed fields, only Information Elements.
+type ProbeRequest struct {
+	IEs []IE
+}
+
+// ParseProbeRequest decodes the Information Elements of a Probe Request
+// frame body.
+func ParseProbeRequest(body []byte) (*ProbeRequest, error) {
+	return &ProbeRequest{IEs: ParseIEs(body)}, nil
+}
+
+// A ProbeResponse is the decoded body of a management frame with
+// SubtypeProbeResponse. Its fixed fields are identical to a Beacon's.
+type ProbeResponse struct {
+	Timestamp      uint64
+	Interval       uint16
+	CapabilityInfo CapabilityInfo
+	IEs            []IE
+}
+
+// ParseProbeResponse decodes the fixed fields and Information Elements of
+// a Probe Response frame body.
+func ParseProbeResponse(body []byte) (*ProbeResponse, error) {
+	if len(body) < 12 {
+		return nil, ErrFrameTooShort
+	}
+	return &ProbeResponse{
+		Timestamp:      binary.LittleEndian.Uint64(body[0:8]),
+		Interval:       binary.LittleEndian.Uint16(body[8:10]),
+		CapabilityInfo: CapabilityInfo(binary.LittleEndian.Uint16(body[10:12])),
+		IEs:            ParseIEs(body[12:]),
+	}, nil
+}
+
+// An AssociationRequest is the decoded body of a management frame with
+// SubtypeAssocRequest.
+type AssociationRequest struct {
+	CapabilityInfo CapabilityInfo
+	ListenInterval uint16
+	IEs            []IE
+}
+
+// ParseAssociationRequest decodes the fixed fields and Information
+// Elements of an Association Request frame body.
+func ParseAssociationRequest(body []byte) (*AssociationRequest, error) {
+	if len(body) < 4 {
+		return nil, ErrFrameTooShort
+	}
+	return &AssociationRequest{
+		CapabilityInfo: CapabilityInfo(binary.LittleEndian.Uint16(body[0:2])),
+		ListenInterval: binary.LittleEndian.Uint16(body[2:4]),
+		IEs:            ParseIEs(body[4:]),
+	}, nil
+}
+
+// An AssociationResponse is the decoded body of a management frame with
+// SubtypeAssocResponse.
+type AssociationResponse struct {
+	CapabilityInfo CapabilityInfo
+	StatusCode     uint16
+	AssociationID  uint16
+	IEs            []IE
+}
+
+// ParseAssociationResponse decodes the fixed fields and Information
+// Elements of an Association Response frame body.
+func ParseAssociationResponse(body []byte) (*AssociationResponse, error) {
+	if len(body) < 6 {
+		return nil, ErrFrameTooShort
+	}
+	return &AssociationResponse{
+		CapabilityInfo: CapabilityInfo(binary.LittleEndian.Uint16(body[0:2])),
+		StatusCode:     binary.LittleEndian.Uint16(body[2:4]),
+		AssociationID:  binary.LittleEndian.Uint16(body[4:6]) &^ (0x3 << 14),
+		IEs:            ParseIEs(body[6:]),
+	}, nil
+}
+
+// An Authentication is the decoded body of a management frame with
+// SubtypeAuthentication.
+type Authentication struct {
+	AlgorithmNumber   uint16
+	TransactionSeqNum uint16
+	StatusCode        uint16
+	IEs               []IE
+}
+
+// ParseAuthentication decodes the fixed fields and Information Elements
+// of an Authentication frame body.
+func ParseAuthentication(body []byte) (*Authentication, error) {
+	if len(body) < 6 {
+		return nil, ErrFrameTooShort
+	}
+	return &Authentication{
+		AlgorithmNumber:   binary.LittleEndian.Uint16(body[0:2]),
+		TransactionSeqNum: binary.LittleEndian.Uint16(body[2:4]),
+		StatusCode:        binary.LittleEndian.Uint16(body[4:6]),
+		IEs:               ParseIEs(body[6:]),
+	}, nil
+}
+
+// A Deauthentication is the decoded body of a management frame with
+// SubtypeDeauthentication.
+type Deauthentication struct {
+	ReasonCode uint16
+}
+
+// ParseDeauthentication decodes the fixed field of a Deauthentication
+// frame body.
+func ParseDeauthentication(body []byte) (*Deauthentication, error) {
+	if len(body) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	return &Deauthentication{ReasonCode: binary.LittleEndian.Uint16(body[0:2])}, nil
+}
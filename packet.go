@@ -1,21 +1,5 @@
 package gofi
 
-type MACPacket struct {
-	// TODO: have some legit fields here.
-	RawData []byte
-}
-
-// ParseMACPacket decodes a MAC packet.
-func ParseMACPacket(data []byte) (*MACPacket, error) {
-	// TODO: do some actual parsing here.
-	return &MACPacket{data}, nil
-}
-
-func (m *MACPacket) Encode() []byte {
-	// TODO: do some legit encoding here.
-	return m.RawData
-}
-
 // RadioInfo contains supplemental information that some hardware supports.
 // Any unavailable fields will be set to 0.
 type RadioInfo struct {
@@ -32,9 +16,53 @@ type RadioInfo struct {
 	// More info can be found here under IEEE80211_RADIOTAP_DBM_TX_POWER:
 	// http://www.opensource.apple.com/source/tcpdump/tcpdump-16/tcpdump/ieee802_11_radio.h
 	TransmitPower int
+
+	// Rate is the data rate the frame was sent at, if known.
+	Rate DataRate
+
+	// MCS is the HT/VHT rate the frame was sent at, if known. It is nil
+	// for frames sent at a legacy rate.
+	MCS *MCS
+
+	// Flags carries the radiotap Flags field, including whether an FCS
+	// trailer is present and whether it validated.
+	Flags RadiotapFlags
+
+	// BadFCS reports whether the hardware flagged this frame's FCS as
+	// invalid. It is only meaningful when Flags.HasFCS() is true.
+	BadFCS bool
+}
+
+// RadioInfoFromRadiotap builds a RadioInfo summary from a fully decoded
+// RadioTap header, for Handle implementations that capture via radiotap.
+func RadioInfoFromRadiotap(rt *RadioTap) *RadioInfo {
+	info := &RadioInfo{}
+	if rt.ChannelFreq != nil {
+		info.Frequency = *rt.ChannelFreq
+	}
+	if rt.DBMAntennaNoise != nil {
+		info.NoisePower = *rt.DBMAntennaNoise
+	}
+	if rt.DBMAntennaSignal != nil {
+		info.SignalPower = *rt.DBMAntennaSignal
+	}
+	if rt.DBMTXPower != nil {
+		info.TransmitPower = *rt.DBMTXPower
+	}
+	if rt.Rate != nil {
+		info.Rate = *rt.Rate
+	}
+	info.MCS = rt.MCS
+	if rt.Flags != nil {
+		info.Flags = *rt.Flags
+		info.BadFCS = rt.Flags.BadFCS()
+	}
+	return info
 }
 
+// A RadioPacket pairs a decoded Frame with the RadioInfo it was received
+// with, if any.
 type RadioPacket struct {
-	MACPacket
+	Frame
 	RadioInfo *RadioInfo
 }
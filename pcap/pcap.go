@@ -0,0 +1,215 @@
+// Package pcap persists gofi capture streams to disk as classic pcap or
+// pcapng files, and replays them back so offline analysis can reuse the
+// same (gofi.Frame, *gofi.RadioInfo) pipeline as live capture.
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+// Link-layer header types, as assigned by the tcpdump.org LINKTYPE_ registry.
+const (
+	LinkTypeIEEE80211         = 105
+	LinkTypeIEEE80211Radiotap = 127
+)
+
+// ErrUnsupportedFormat is returned by Open and NewReader when the input
+// is not a recognized pcap or pcapng file, or uses a link type this
+// package does not understand.
+var ErrUnsupportedFormat = errors.New("pcap: unsupported or unrecognized file format")
+
+const classicMagic = 0xa1b2c3d4
+
+// A Writer persists a stream of received frames to disk.
+type Writer interface {
+	// WriteFrame appends one captured frame. info may be nil if the
+	// device that captured it does not support radio information.
+	WriteFrame(f gofi.Frame, info *gofi.RadioInfo, t time.Time) error
+
+	// Close flushes any buffered data. It does not close the underlying
+	// io.Writer.
+	Close() error
+}
+
+// A Reader replays a previously written capture file.
+type Reader interface {
+	// ReadFrame returns the next captured frame, or io.EOF once the file
+	// is exhausted.
+	ReadFrame() (gofi.Frame, *gofi.RadioInfo, time.Time, error)
+}
+
+// NewWriter creates a classic pcap Writer. useRadiotap selects the link
+// type: LinkTypeIEEE80211Radiotap if true, LinkTypeIEEE80211 otherwise.
+// Every subsequent WriteFrame call must agree with that choice; RadioInfo
+// passed when useRadiotap is false is ignored.
+func NewWriter(w io.Writer, useRadiotap bool) (Writer, error) {
+	linkType := uint32(LinkTypeIEEE80211)
+	if useRadiotap {
+		linkType = LinkTypeIEEE80211Radiotap
+	}
+
+	var hdr [24]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], classicMagic)
+	binary.LittleEndian.PutUint16(hdr[4:6], 2)
+	binary.LittleEndian.PutUint16(hdr[6:8], 4)
+	binary.LittleEndian.PutUint32(hdr[16:20], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:24], linkType)
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return &writer{w: bw, useRadiotap: useRadiotap}, nil
+}
+
+type writer struct {
+	w           *bufio.Writer
+	useRadiotap bool
+}
+
+func (pw *writer) WriteFrame(f gofi.Frame, info *gofi.RadioInfo, t time.Time) error {
+	data := f.Encode()
+	if pw.useRadiotap {
+		data = append(radiotapFor(info).Encode(), data...)
+	}
+
+	var rec [16]byte
+	binary.LittleEndian.PutUint32(rec[0:4], uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(rec[4:8], uint32(t.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(rec[8:12], uint32(len(data)))
+	binary.LittleEndian.PutUint32(rec[12:16], uint32(len(data)))
+
+	if _, err := pw.w.Write(rec[:]); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(data)
+	return err
+}
+
+func (pw *writer) Close() error {
+	return pw.w.Flush()
+}
+
+// radiotapFor synthesizes a minimal RadioTap header carrying whatever
+// RadioInfo is available. info may be nil, in which case an empty header
+// (no optional fields present) is produced.
+func radiotapFor(info *gofi.RadioInfo) *gofi.RadioTap {
+	rt := &gofi.RadioTap{}
+	if info == nil {
+		return rt
+	}
+	if info.Frequency != 0 {
+		freq := info.Frequency
+		rt.ChannelFreq = &freq
+		flags := gofi.RadiotapChannelFlags(0)
+		rt.ChannelFlags = &flags
+	}
+	if info.SignalPower != 0 {
+		v := info.SignalPower
+		rt.DBMAntennaSignal = &v
+	}
+	if info.NoisePower != 0 {
+		v := info.NoisePower
+		rt.DBMAntennaNoise = &v
+	}
+	if info.TransmitPower != 0 {
+		v := info.TransmitPower
+		rt.DBMTXPower = &v
+	}
+	if info.Rate != 0 {
+		r := info.Rate
+		rt.Rate = &r
+	}
+	rt.MCS = info.MCS
+	flags := info.Flags
+	rt.Flags = &flags
+	return rt
+}
+
+// NewReader opens a classic pcap Reader. It returns ErrUnsupportedFormat
+// if the stream is not a little-endian classic pcap file with a link type
+// this package understands.
+func NewReader(r io.Reader) (Reader, error) {
+	br := bufio.NewReader(r)
+	var hdr [24]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(hdr[0:4]) != classicMagic {
+		return nil, ErrUnsupportedFormat
+	}
+	linkType := binary.LittleEndian.Uint32(hdr[20:24])
+	if linkType != LinkTypeIEEE80211 && linkType != LinkTypeIEEE80211Radiotap {
+		return nil, ErrUnsupportedFormat
+	}
+	snaplen := binary.LittleEndian.Uint32(hdr[16:20])
+	return &reader{r: br, useRadiotap: linkType == LinkTypeIEEE80211Radiotap, snaplen: snaplen}, nil
+}
+
+type reader struct {
+	r           *bufio.Reader
+	useRadiotap bool
+	snaplen     uint32
+}
+
+func (pr *reader) ReadFrame() (gofi.Frame, *gofi.RadioInfo, time.Time, error) {
+	var rec [16]byte
+	if _, err := io.ReadFull(pr.r, rec[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return gofi.Frame{}, nil, time.Time{}, err
+	}
+	sec := binary.LittleEndian.Uint32(rec[0:4])
+	usec := binary.LittleEndian.Uint32(rec[4:8])
+	capLen := binary.LittleEndian.Uint32(rec[8:12])
+	if capLen > pr.snaplen {
+		return gofi.Frame{}, nil, time.Time{}, ErrUnsupportedFormat
+	}
+	t := time.Unix(int64(sec), int64(usec)*1000).UTC()
+
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		return gofi.Frame{}, nil, time.Time{}, err
+	}
+
+	var info *gofi.RadioInfo
+	if pr.useRadiotap {
+		rt, rest, err := gofi.ParseRadiotap(data)
+		if err != nil {
+			return gofi.Frame{}, nil, time.Time{}, err
+		}
+		info = gofi.RadioInfoFromRadiotap(rt)
+		data = rest
+	}
+
+	f, err := gofi.ParseFrame(data, info != nil && info.Flags.HasFCS())
+	if err != nil {
+		return gofi.Frame{}, nil, time.Time{}, err
+	}
+	return *f, info, t, nil
+}
+
+// Open opens either a classic pcap or pcapng file, detecting the format
+// from its magic number.
+func Open(r io.Reader) (Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	switch binary.LittleEndian.Uint32(magic) {
+	case classicMagic:
+		return NewReader(br)
+	case blockTypeSectionHeader:
+		return newNGReader(br)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
@@ -0,0 +1,189 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+func testFrame() gofi.Frame {
+	return gofi.Frame{
+		FrameControl: gofi.FrameControl{Type: gofi.FrameTypeControl, Subtype: gofi.SubtypeACK},
+		DurationID:   0x123,
+		Addr1:        mustAddr("00:11:22:33:44:55"),
+	}
+}
+
+func mustAddr(s string) net.HardwareAddr {
+	a, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func TestClassicPcapRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, false)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	f := testFrame()
+	ts := time.Unix(1700000000, 500000).UTC()
+	if err := w.WriteFrame(f, nil, ts); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	got, info, _, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if info != nil {
+		t.Fatalf("info = %v, want nil", info)
+	}
+	if got.Addr1.String() != f.Addr1.String() {
+		t.Fatalf("Addr1 = %v, want %v", got.Addr1, f.Addr1)
+	}
+}
+
+func TestClassicPcapRadiotapFCSStripped(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, true)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	f := testFrame()
+	f.FCS = new(uint32) // non-nil so Encode appends a computed FCS trailer
+	info := &gofi.RadioInfo{Flags: gofi.RadiotapFlagFCS}
+	if err := w.WriteFrame(f, info, time.Unix(0, 0)); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	w.Close()
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	got, _, _, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if len(got.Body) != 0 {
+		t.Fatalf("Body = %v, want empty (FCS trailer should have been stripped)", got.Body)
+	}
+}
+
+func TestNGPcapRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewNGWriter(&buf, "mon0", 6, false)
+	if err != nil {
+		t.Fatalf("NewNGWriter: %v", err)
+	}
+	f := testFrame()
+	if err := w.WriteFrame(f, nil, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	w.Close()
+
+	r, err := Open(&buf)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, _, _, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if got.Addr1.String() != f.Addr1.String() {
+		t.Fatalf("Addr1 = %v, want %v", got.Addr1, f.Addr1)
+	}
+}
+
+func TestClassicPcapRejectsOversizedCapLen(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, false)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.WriteFrame(testFrame(), nil, time.Unix(0, 0)); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	w.Close()
+
+	raw := buf.Bytes()
+	// Global header is 24 bytes; the record's capLen field is the third
+	// uint32 in its 16-byte header, i.e. bytes [24+8:24+12).
+	binary.LittleEndian.PutUint32(raw[24+8:24+12], 0x7fffffff)
+
+	r, err := NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if _, _, _, err := r.ReadFrame(); err != ErrUnsupportedFormat {
+		t.Fatalf("err = %v, want ErrUnsupportedFormat (must not attempt a huge allocation)", err)
+	}
+}
+
+func TestNGReaderRejectsOversizedBlockLength(t *testing.T) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(blockTypeSectionHeader))
+	binary.Write(&buf, binary.LittleEndian, uint32(28))
+	binary.Write(&buf, binary.LittleEndian, uint32(byteOrderMagic))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(0))
+	binary.Write(&buf, binary.LittleEndian, uint64(0xFFFFFFFFFFFFFFFF))
+	binary.Write(&buf, binary.LittleEndian, uint32(28))
+
+	// A bogus block header claiming a length far larger than any real
+	// block this package writes.
+	binary.Write(&buf, binary.LittleEndian, uint32(blockTypeEnhancedPacket))
+	binary.Write(&buf, binary.LittleEndian, uint32(0x7fffffff))
+
+	if _, err := Open(bytes.NewReader(buf.Bytes())); err != ErrUnsupportedFormat {
+		t.Fatalf("err = %v, want ErrUnsupportedFormat (must not attempt a huge allocation)", err)
+	}
+}
+
+func TestNGReadFrameRejectsOversizedCapLen(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewNGWriter(&buf, "mon0", 0, false)
+	if err != nil {
+		t.Fatalf("NewNGWriter: %v", err)
+	}
+	if err := w.WriteFrame(testFrame(), nil, time.Unix(0, 0)); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	w.Close()
+
+	raw := buf.Bytes()
+	// Find the Enhanced Packet Block's capLen field (offset 12 into its
+	// body, i.e. 8 (block header) + 12 = 20 bytes in) and tamper with it
+	// so it claims far more captured data than the block actually holds.
+	idx := bytes.LastIndex(raw, []byte{0x06, 0x00, 0x00, 0x00})
+	if idx < 0 {
+		t.Fatalf("could not locate Enhanced Packet Block in output")
+	}
+	capLenOff := idx + 8 + 12
+	raw[capLenOff] = 0xff
+	raw[capLenOff+1] = 0xff
+	raw[capLenOff+2] = 0xff
+	raw[capLenOff+3] = 0x7f
+
+	r, err := Open(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, _, _, err := r.ReadFrame(); err != ErrUnsupportedFormat {
+		t.Fatalf("err = %v, want ErrUnsupportedFormat (must not panic)", err)
+	}
+}
@@ -0,0 +1,282 @@
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+// pcapng block types this package reads and writes.
+const (
+	blockTypeSectionHeader  = 0x0A0D0D0A
+	blockTypeInterfaceDesc  = 0x00000001
+	blockTypeEnhancedPacket = 0x00000006
+)
+
+const byteOrderMagic = 0x1A2B3C4D
+
+// maxBlockSize bounds the total length pcapng declares for a single block.
+// It is far larger than any legitimate Interface Description or Enhanced
+// Packet Block this package writes, and exists solely to keep a corrupted
+// or adversarial length field from driving a multi-gigabyte allocation
+// before the read that would actually fail on truncated input.
+const maxBlockSize = 16 << 20
+
+// pcapng Interface Description Block option codes.
+const (
+	optEndOfOpt uint16 = 0
+	optIfName   uint16 = 2
+	optIfDescr  uint16 = 3
+)
+
+// NewNGWriter creates a pcapng Writer with a single interface, described
+// by name and channel (name is written as the if_name option and channel
+// as an if_description option reading "channel N"; pass 0 if unknown and
+// the option is omitted). The readers in this package do not decode
+// Interface Description options, so name and channel are not returned
+// when the file is read back with Open.
+func NewNGWriter(w io.Writer, name string, channel int, useRadiotap bool) (Writer, error) {
+	linkType := uint16(LinkTypeIEEE80211)
+	if useRadiotap {
+		linkType = LinkTypeIEEE80211Radiotap
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeSectionHeader(bw); err != nil {
+		return nil, err
+	}
+	if err := writeInterfaceDescription(bw, linkType, name, channel); err != nil {
+		return nil, err
+	}
+	return &ngWriter{w: bw, useRadiotap: useRadiotap}, nil
+}
+
+type ngWriter struct {
+	w           *bufio.Writer
+	useRadiotap bool
+}
+
+func (nw *ngWriter) WriteFrame(f gofi.Frame, info *gofi.RadioInfo, t time.Time) error {
+	data := f.Encode()
+	if nw.useRadiotap {
+		data = append(radiotapFor(info).Encode(), data...)
+	}
+	return writeEnhancedPacket(nw.w, 0, t, data)
+}
+
+func (nw *ngWriter) Close() error {
+	return nw.w.Flush()
+}
+
+func pad4(n int) int {
+	if rem := n % 4; rem != 0 {
+		return n + (4 - rem)
+	}
+	return n
+}
+
+func writeBlock(w io.Writer, blockType uint32, body []byte) error {
+	total := 12 + pad4(len(body))
+	buf := make([]byte, total)
+	binary.LittleEndian.PutUint32(buf[0:4], blockType)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(total))
+	copy(buf[8:], body)
+	binary.LittleEndian.PutUint32(buf[total-4:total], uint32(total))
+	_, err := w.Write(buf)
+	return err
+}
+
+func writeSectionHeader(w io.Writer) error {
+	body := make([]byte, 16)
+	binary.LittleEndian.PutUint32(body[0:4], byteOrderMagic)
+	binary.LittleEndian.PutUint16(body[4:6], 1)
+	binary.LittleEndian.PutUint16(body[6:8], 0)
+	binary.LittleEndian.PutUint64(body[8:16], 0xFFFFFFFFFFFFFFFF) // section length unknown
+	return writeBlock(w, blockTypeSectionHeader, body)
+}
+
+func encodeOption(code uint16, value []byte) []byte {
+	opt := make([]byte, 4+pad4(len(value)))
+	binary.LittleEndian.PutUint16(opt[0:2], code)
+	binary.LittleEndian.PutUint16(opt[2:4], uint16(len(value)))
+	copy(opt[4:], value)
+	return opt
+}
+
+func writeInterfaceDescription(w io.Writer, linkType uint16, name string, channel int) error {
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:2], linkType)
+	binary.LittleEndian.PutUint32(body[4:8], 65535)
+
+	if name != "" {
+		body = append(body, encodeOption(optIfName, []byte(name))...)
+	}
+	if channel != 0 {
+		descr := "channel " + itoa(channel)
+		body = append(body, encodeOption(optIfDescr, []byte(descr))...)
+	}
+	body = append(body, encodeOption(optEndOfOpt, nil)...)
+
+	return writeBlock(w, blockTypeInterfaceDesc, body)
+}
+
+func writeEnhancedPacket(w io.Writer, ifaceID uint32, t time.Time, data []byte) error {
+	micros := uint64(t.UnixNano() / 1000)
+	body := make([]byte, 20+pad4(len(data)))
+	binary.LittleEndian.PutUint32(body[0:4], ifaceID)
+	binary.LittleEndian.PutUint32(body[4:8], uint32(micros>>32))
+	binary.LittleEndian.PutUint32(body[8:12], uint32(micros))
+	binary.LittleEndian.PutUint32(body[12:16], uint32(len(data)))
+	binary.LittleEndian.PutUint32(body[16:20], uint32(len(data)))
+	copy(body[20:], data)
+	return writeBlock(w, blockTypeEnhancedPacket, body)
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	var buf [20]byte
+	i := len(buf)
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	for n > 0 {
+		i--
+		buf[i] = byte('0' + n%10)
+		n /= 10
+	}
+	if neg {
+		i--
+		buf[i] = '-'
+	}
+	return string(buf[i:])
+}
+
+// An ngReader reads pcapng blocks looking for Interface Description Blocks
+// (to learn each interface's link type) and Enhanced Packet Blocks.
+type ngReader struct {
+	r         *bufio.Reader
+	linkTypes map[uint32]uint16
+	pending   *ngBlock
+}
+
+func newNGReader(r *bufio.Reader) (Reader, error) {
+	nr := &ngReader{r: r, linkTypes: map[uint32]uint16{}}
+	var nextIfaceID uint32
+	// Consume leading Section Header and Interface Description blocks;
+	// stop as soon as we see anything else, which ReadFrame will then
+	// pick up as the first packet block.
+	for {
+		blockType, body, err := nr.readBlock()
+		if err != nil {
+			return nil, err
+		}
+		switch blockType {
+		case blockTypeSectionHeader:
+			continue
+		case blockTypeInterfaceDesc:
+			if len(body) < 8 {
+				return nil, ErrUnsupportedFormat
+			}
+			nr.linkTypes[nextIfaceID] = binary.LittleEndian.Uint16(body[0:2])
+			nextIfaceID++
+			continue
+		default:
+			nr.pending = &ngBlock{blockType: blockType, body: body}
+			return nr, nil
+		}
+	}
+}
+
+type ngBlock struct {
+	blockType uint32
+	body      []byte
+}
+
+func (nr *ngReader) readBlock() (uint32, []byte, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(nr.r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	blockType := binary.LittleEndian.Uint32(hdr[0:4])
+	total := binary.LittleEndian.Uint32(hdr[4:8])
+	if total < 12 || total > maxBlockSize {
+		return 0, nil, ErrUnsupportedFormat
+	}
+	rest := make([]byte, total-8)
+	if _, err := io.ReadFull(nr.r, rest); err != nil {
+		return 0, nil, err
+	}
+	body := rest[:len(rest)-4]
+	return blockType, body, nil
+}
+
+func (nr *ngReader) ReadFrame() (gofi.Frame, *gofi.RadioInfo, time.Time, error) {
+	var blockType uint32
+	var body []byte
+	if nr.pending != nil {
+		blockType, body = nr.pending.blockType, nr.pending.body
+		nr.pending = nil
+	} else {
+		var err error
+		blockType, body, err = nr.readBlock()
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return gofi.Frame{}, nil, time.Time{}, err
+		}
+	}
+
+	for blockType == blockTypeInterfaceDesc {
+		// Interfaces added mid-capture; record and keep scanning.
+		if len(body) >= 8 {
+			nr.linkTypes[uint32(len(nr.linkTypes))] = binary.LittleEndian.Uint16(body[0:2])
+		}
+		var err error
+		blockType, body, err = nr.readBlock()
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return gofi.Frame{}, nil, time.Time{}, err
+		}
+	}
+
+	if blockType != blockTypeEnhancedPacket || len(body) < 20 {
+		return gofi.Frame{}, nil, time.Time{}, ErrUnsupportedFormat
+	}
+
+	ifaceID := binary.LittleEndian.Uint32(body[0:4])
+	tsHigh := binary.LittleEndian.Uint32(body[4:8])
+	tsLow := binary.LittleEndian.Uint32(body[8:12])
+	capLen := binary.LittleEndian.Uint32(body[12:16])
+	micros := (uint64(tsHigh) << 32) | uint64(tsLow)
+	t := time.Unix(0, int64(micros)*1000).UTC()
+
+	if uint64(capLen) > uint64(len(body)-20) {
+		return gofi.Frame{}, nil, time.Time{}, ErrUnsupportedFormat
+	}
+	data := body[20 : 20+capLen]
+
+	var info *gofi.RadioInfo
+	if nr.linkTypes[ifaceID] == LinkTypeIEEE80211Radiotap {
+		rt, rest, err := gofi.ParseRadiotap(data)
+		if err != nil {
+			return gofi.Frame{}, nil, time.Time{}, err
+		}
+		info = gofi.RadioInfoFromRadiotap(rt)
+		data = rest
+	}
+
+	f, err := gofi.ParseFrame(data, info != nil && info.Flags.HasFCS())
+	if err != nil {
+		return gofi.Frame{}, nil, time.Time{}, err
+	}
+	return *f, info, t, nil
+}
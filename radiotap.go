@@ -0,0 +1,423 @@
+package gofi
+
+import "encoding/binary"
+
+// RadiotapFlags holds the bits of the radiotap Flags field.
+type RadiotapFlags uint8
+
+// Radiotap Flags bits.
+const (
+	RadiotapFlagCFP           RadiotapFlags = 1 << 0
+	RadiotapFlagShortPreamble RadiotapFlags = 1 << 1
+	RadiotapFlagWEP           RadiotapFlags = 1 << 2
+	RadiotapFlagFragmented    RadiotapFlags = 1 << 3
+	RadiotapFlagFCS           RadiotapFlags = 1 << 4
+	RadiotapFlagDataPad       RadiotapFlags = 1 << 5
+	RadiotapFlagBadFCS        RadiotapFlags = 1 << 6
+	RadiotapFlagShortGI       RadiotapFlags = 1 << 7
+)
+
+// HasFCS reports whether the frame includes a trailing FCS.
+func (f RadiotapFlags) HasFCS() bool { return f&RadiotapFlagFCS != 0 }
+
+// BadFCS reports whether the FCS failed to validate.
+func (f RadiotapFlags) BadFCS() bool { return f&RadiotapFlagBadFCS != 0 }
+
+// ShortGI reports whether the frame was sent with a short guard interval.
+func (f RadiotapFlags) ShortGI() bool { return f&RadiotapFlagShortGI != 0 }
+
+// RadiotapChannelFlags holds the bits of the radiotap Channel flags word.
+type RadiotapChannelFlags uint16
+
+// Radiotap Channel flags bits.
+const (
+	ChannelFlagTurbo          RadiotapChannelFlags = 0x0010
+	ChannelFlagCCK            RadiotapChannelFlags = 0x0020
+	ChannelFlagOFDM           RadiotapChannelFlags = 0x0040
+	ChannelFlag2GHz           RadiotapChannelFlags = 0x0080
+	ChannelFlag5GHz           RadiotapChannelFlags = 0x0100
+	ChannelFlagPassive        RadiotapChannelFlags = 0x0200
+	ChannelFlagDynamicCCKOFDM RadiotapChannelFlags = 0x0400
+	ChannelFlagGFSK           RadiotapChannelFlags = 0x0800
+)
+
+// RadiotapRXFlags holds the bits of the radiotap RX flags word.
+type RadiotapRXFlags uint16
+
+// RadiotapRXFlagBadPLCP indicates the PLCP header failed its checksum.
+const RadiotapRXFlagBadPLCP RadiotapRXFlags = 1 << 1
+
+// BadPLCP reports whether the PLCP header failed its checksum.
+func (f RadiotapRXFlags) BadPLCP() bool { return f&RadiotapRXFlagBadPLCP != 0 }
+
+// MCS describes 802.11n rate parameters, either observed on receive or
+// requested for transmit via TXParams.
+type MCS struct {
+	Index          uint8
+	Bandwidth40MHz bool
+	ShortGI        bool
+	FECLDPC        bool
+	STBCStreams    uint8
+}
+
+func parseMCS(b []byte) MCS {
+	known, flags, index := b[0], b[1], b[2]
+	m := MCS{Index: index}
+	if known&0x01 != 0 {
+		m.Bandwidth40MHz = flags&0x03 != 0
+	}
+	if known&0x04 != 0 {
+		m.ShortGI = flags&0x04 != 0
+	}
+	if known&0x10 != 0 {
+		m.FECLDPC = flags&0x10 != 0
+	}
+	if known&0x20 != 0 {
+		m.STBCStreams = (flags >> 5) & 0x3
+	}
+	return m
+}
+
+func (m MCS) encode() [3]byte {
+	// Report every field we might set as "known".
+	known := uint8(0x01 | 0x04 | 0x10 | 0x20)
+	var flags uint8
+	if m.Bandwidth40MHz {
+		flags |= 0x01
+	}
+	if m.ShortGI {
+		flags |= 0x04
+	}
+	if m.FECLDPC {
+		flags |= 0x10
+	}
+	flags |= (m.STBCStreams & 0x3) << 5
+	return [3]byte{known, flags, m.Index}
+}
+
+// AMPDUStatus describes the A-MPDU an aggregated frame belonged to.
+type AMPDUStatus struct {
+	ReferenceNumber uint32
+	Flags           uint16
+	DelimiterCRC    uint8
+}
+
+// VHTInfo describes 802.11ac rate parameters.
+type VHTInfo struct {
+	Known      uint16
+	Flags      uint8
+	Bandwidth  uint8
+	MCSNSS     [4]uint8
+	Coding     uint8
+	GroupID    uint8
+	PartialAID uint16
+}
+
+// RadiotapFHSS is the radiotap FHSS field, describing the hop set and
+// pattern of a frame received over a frequency-hopping PHY.
+type RadiotapFHSS struct {
+	HopSet     uint8
+	HopPattern uint8
+}
+
+// RadiotapTimestamp is the radiotap Timestamp field, used by hardware that
+// timestamps frames more precisely than TSFT allows.
+type RadiotapTimestamp struct {
+	Timestamp    uint64
+	Accuracy     uint16
+	UnitPosition uint8
+	Flags        uint8
+}
+
+// A RadioTap is a decoded radiotap header, the de facto standard container
+// that link-type 127 captures use to carry per-frame radio metadata ahead
+// of the raw 802.11 MAC frame.
+//
+// Every field beyond Version/Pad/Present is a pointer and nil when absent.
+type RadioTap struct {
+	Version uint8
+	Pad     uint8
+
+	// Present holds the raw it_present bitmap(s), including any chained
+	// extensions (indicated by the high bit of each word).
+	Present []uint32
+
+	TSFT             *uint64
+	Flags            *RadiotapFlags
+	Rate             *DataRate
+	ChannelFreq      *int
+	ChannelFlags     *RadiotapChannelFlags
+	FHSS             *RadiotapFHSS
+	DBMAntennaSignal *int
+	DBMAntennaNoise  *int
+	LockQuality      *uint16
+	TXAttenuation    *uint16
+	DBMTXPower       *int
+	Antenna          *uint8
+	RXFlags          *RadiotapRXFlags
+	MCS              *MCS
+	AMPDUStatus      *AMPDUStatus
+	VHT              *VHTInfo
+	Timestamp        *RadiotapTimestamp
+}
+
+// radiotapField describes one optional field: its bit in it_present, its
+// required alignment and size in bytes, and how to move it in and out of a
+// RadioTap. Fields must be listed in ascending bit order, matching the
+// order they appear on the wire.
+type radiotapField struct {
+	bit     uint
+	align   int
+	size    int
+	present func(*RadioTap) bool
+	decode  func(*RadioTap, []byte)
+	encode  func(*RadioTap, []byte)
+}
+
+var radiotapFields = []radiotapField{
+	{
+		bit: 0, align: 8, size: 8,
+		present: func(rt *RadioTap) bool { return rt.TSFT != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := binary.LittleEndian.Uint64(b); rt.TSFT = &v },
+		encode:  func(rt *RadioTap, b []byte) { binary.LittleEndian.PutUint64(b, *rt.TSFT) },
+	},
+	{
+		bit: 1, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.Flags != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := RadiotapFlags(b[0]); rt.Flags = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = uint8(*rt.Flags) },
+	},
+	{
+		bit: 2, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.Rate != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := DataRate(b[0]); rt.Rate = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = uint8(*rt.Rate) },
+	},
+	{
+		bit: 3, align: 2, size: 4,
+		present: func(rt *RadioTap) bool { return rt.ChannelFreq != nil },
+		decode: func(rt *RadioTap, b []byte) {
+			freq := int(binary.LittleEndian.Uint16(b[0:2]))
+			flags := RadiotapChannelFlags(binary.LittleEndian.Uint16(b[2:4]))
+			rt.ChannelFreq = &freq
+			rt.ChannelFlags = &flags
+		},
+		encode: func(rt *RadioTap, b []byte) {
+			binary.LittleEndian.PutUint16(b[0:2], uint16(*rt.ChannelFreq))
+			if rt.ChannelFlags != nil {
+				binary.LittleEndian.PutUint16(b[2:4], uint16(*rt.ChannelFlags))
+			}
+		},
+	},
+	{
+		bit: 4, align: 2, size: 2,
+		present: func(rt *RadioTap) bool { return rt.FHSS != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := RadiotapFHSS{HopSet: b[0], HopPattern: b[1]}; rt.FHSS = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = rt.FHSS.HopSet; b[1] = rt.FHSS.HopPattern },
+	},
+	{
+		bit: 5, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.DBMAntennaSignal != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := int(int8(b[0])); rt.DBMAntennaSignal = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = byte(int8(*rt.DBMAntennaSignal)) },
+	},
+	{
+		bit: 6, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.DBMAntennaNoise != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := int(int8(b[0])); rt.DBMAntennaNoise = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = byte(int8(*rt.DBMAntennaNoise)) },
+	},
+	{
+		bit: 7, align: 2, size: 2,
+		present: func(rt *RadioTap) bool { return rt.LockQuality != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := binary.LittleEndian.Uint16(b); rt.LockQuality = &v },
+		encode:  func(rt *RadioTap, b []byte) { binary.LittleEndian.PutUint16(b, *rt.LockQuality) },
+	},
+	{
+		bit: 8, align: 2, size: 2,
+		present: func(rt *RadioTap) bool { return rt.TXAttenuation != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := binary.LittleEndian.Uint16(b); rt.TXAttenuation = &v },
+		encode:  func(rt *RadioTap, b []byte) { binary.LittleEndian.PutUint16(b, *rt.TXAttenuation) },
+	},
+	{
+		bit: 10, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.DBMTXPower != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := int(int8(b[0])); rt.DBMTXPower = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = byte(int8(*rt.DBMTXPower)) },
+	},
+	{
+		bit: 11, align: 1, size: 1,
+		present: func(rt *RadioTap) bool { return rt.Antenna != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := b[0]; rt.Antenna = &v },
+		encode:  func(rt *RadioTap, b []byte) { b[0] = *rt.Antenna },
+	},
+	{
+		bit: 14, align: 2, size: 2,
+		present: func(rt *RadioTap) bool { return rt.RXFlags != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := RadiotapRXFlags(binary.LittleEndian.Uint16(b)); rt.RXFlags = &v },
+		encode:  func(rt *RadioTap, b []byte) { binary.LittleEndian.PutUint16(b, uint16(*rt.RXFlags)) },
+	},
+	{
+		bit: 19, align: 1, size: 3,
+		present: func(rt *RadioTap) bool { return rt.MCS != nil },
+		decode:  func(rt *RadioTap, b []byte) { v := parseMCS(b); rt.MCS = &v },
+		encode:  func(rt *RadioTap, b []byte) { enc := rt.MCS.encode(); copy(b, enc[:]) },
+	},
+	{
+		bit: 20, align: 4, size: 8,
+		present: func(rt *RadioTap) bool { return rt.AMPDUStatus != nil },
+		decode: func(rt *RadioTap, b []byte) {
+			rt.AMPDUStatus = &AMPDUStatus{
+				ReferenceNumber: binary.LittleEndian.Uint32(b[0:4]),
+				Flags:           binary.LittleEndian.Uint16(b[4:6]),
+				DelimiterCRC:    b[6],
+			}
+		},
+		encode: func(rt *RadioTap, b []byte) {
+			binary.LittleEndian.PutUint32(b[0:4], rt.AMPDUStatus.ReferenceNumber)
+			binary.LittleEndian.PutUint16(b[4:6], rt.AMPDUStatus.Flags)
+			b[6] = rt.AMPDUStatus.DelimiterCRC
+		},
+	},
+	{
+		bit: 21, align: 2, size: 12,
+		present: func(rt *RadioTap) bool { return rt.VHT != nil },
+		decode: func(rt *RadioTap, b []byte) {
+			v := &VHTInfo{
+				Known:     binary.LittleEndian.Uint16(b[0:2]),
+				Flags:     b[2],
+				Bandwidth: b[3],
+				Coding:    b[8],
+				GroupID:   b[9],
+			}
+			copy(v.MCSNSS[:], b[4:8])
+			v.PartialAID = binary.LittleEndian.Uint16(b[10:12])
+			rt.VHT = v
+		},
+		encode: func(rt *RadioTap, b []byte) {
+			binary.LittleEndian.PutUint16(b[0:2], rt.VHT.Known)
+			b[2] = rt.VHT.Flags
+			b[3] = rt.VHT.Bandwidth
+			copy(b[4:8], rt.VHT.MCSNSS[:])
+			b[8] = rt.VHT.Coding
+			b[9] = rt.VHT.GroupID
+			binary.LittleEndian.PutUint16(b[10:12], rt.VHT.PartialAID)
+		},
+	},
+	{
+		bit: 22, align: 8, size: 12,
+		present: func(rt *RadioTap) bool { return rt.Timestamp != nil },
+		decode: func(rt *RadioTap, b []byte) {
+			rt.Timestamp = &RadiotapTimestamp{
+				Timestamp:    binary.LittleEndian.Uint64(b[0:8]),
+				Accuracy:     binary.LittleEndian.Uint16(b[8:10]),
+				UnitPosition: b[10],
+				Flags:        b[11],
+			}
+		},
+		encode: func(rt *RadioTap, b []byte) {
+			binary.LittleEndian.PutUint64(b[0:8], rt.Timestamp.Timestamp)
+			binary.LittleEndian.PutUint16(b[8:10], rt.Timestamp.Accuracy)
+			b[10] = rt.Timestamp.UnitPosition
+			b[11] = rt.Timestamp.Flags
+		},
+	},
+}
+
+func alignUp(offset, align int) int {
+	if align <= 1 {
+		return offset
+	}
+	if rem := offset % align; rem != 0 {
+		return offset + (align - rem)
+	}
+	return offset
+}
+
+// ParseRadiotap decodes a radiotap header and returns it along with the
+// remaining bytes, which are the 802.11 frame the header describes.
+//
+// Decoding of individual fields stops at the first present bit this
+// package does not model; it_len is still authoritative for locating the
+// 802.11 payload, so unsupported fields never corrupt the returned frame
+// bytes, they are just absent from the returned RadioTap.
+func ParseRadiotap(data []byte) (*RadioTap, []byte, error) {
+	if len(data) < 8 {
+		return nil, nil, ErrFrameTooShort
+	}
+	itLen := int(binary.LittleEndian.Uint16(data[2:4]))
+	if len(data) < itLen {
+		return nil, nil, ErrFrameTooShort
+	}
+
+	rt := &RadioTap{Version: data[0], Pad: data[1]}
+
+	off := 4
+	for {
+		if off+4 > itLen {
+			return nil, nil, ErrFrameTooShort
+		}
+		word := binary.LittleEndian.Uint32(data[off:])
+		rt.Present = append(rt.Present, word)
+		off += 4
+		if word&(1<<31) == 0 {
+			break
+		}
+	}
+
+	word := rt.Present[0]
+	cur := off
+	var modeled uint32
+fields:
+	for _, fd := range radiotapFields {
+		// An unmodeled present bit below fd.bit has an unknown size, so
+		// every later field offset would be wrong.
+		if word&(uint32(1)<<fd.bit-1)&^modeled != 0 {
+			break fields
+		}
+		modeled |= 1 << fd.bit
+		if word&(1<<fd.bit) == 0 {
+			continue
+		}
+		cur = alignUp(cur, fd.align)
+		if cur+fd.size > itLen {
+			break fields
+		}
+		fd.decode(rt, data[cur:cur+fd.size])
+		cur += fd.size
+	}
+
+	return rt, data[itLen:], nil
+}
+
+// Encode serializes the radiotap header back to bytes. it_len is computed
+// from the fields that are set; Present is ignored and rebuilt from those
+// fields.
+func (rt *RadioTap) Encode() []byte {
+	var present uint32
+	cur := 8
+	for _, fd := range radiotapFields {
+		if !fd.present(rt) {
+			continue
+		}
+		present |= 1 << fd.bit
+		cur = alignUp(cur, fd.align) + fd.size
+	}
+
+	buf := make([]byte, cur)
+	buf[0] = rt.Version
+	buf[1] = rt.Pad
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(cur))
+	binary.LittleEndian.PutUint32(buf[4:8], present)
+
+	pos := 8
+	for _, fd := range radiotapFields {
+		if !fd.present(rt) {
+			continue
+		}
+		pos = alignUp(pos, fd.align)
+		fd.encode(rt, buf[pos:pos+fd.size])
+		pos += fd.size
+	}
+	return buf
+}
@@ -0,0 +1,26 @@
+package gofi
+
+import "testing"
+
+func TestRadiotapFHSSRoundTrip(t *testing.T) {
+	freq := 2437
+	rt := &RadioTap{
+		FHSS:        &RadiotapFHSS{HopSet: 3, HopPattern: 9},
+		ChannelFreq: &freq,
+	}
+	raw := rt.Encode()
+
+	got, rest, err := ParseRadiotap(append(raw, 0xde, 0xad))
+	if err != nil {
+		t.Fatalf("ParseRadiotap: %v", err)
+	}
+	if len(rest) != 2 || rest[0] != 0xde || rest[1] != 0xad {
+		t.Fatalf("rest = %x, want trailing payload untouched", rest)
+	}
+	if got.FHSS == nil || got.FHSS.HopSet != 3 || got.FHSS.HopPattern != 9 {
+		t.Fatalf("FHSS = %+v, want {HopSet:3 HopPattern:9}", got.FHSS)
+	}
+	if got.ChannelFreq == nil || *got.ChannelFreq != freq {
+		t.Fatalf("ChannelFreq = %v, want %d", got.ChannelFreq, freq)
+	}
+}
@@ -0,0 +1,139 @@
+package remote
+
+import (
+	"net"
+	"sync"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+// A Collector binds one UDP port per interface it is told to listen on,
+// merges the frames received from every Forwarder feeding those ports,
+// and exposes the result as a single virtual gofi.Handle.
+//
+// A Collector is receive-only: SetChannel and Send always return
+// ErrNotSupported, since there is no single device backing the merged
+// stream.
+type Collector struct {
+	conns []*net.UDPConn
+	out   chan *decodedDatagram
+	errCh chan error
+
+	mu      sync.Mutex
+	lastSeq map[uint32]uint64
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// NewCollector binds a UDP listener on each of addrs (host:port, one per
+// capture interface it should merge) and starts merging their streams.
+func NewCollector(addrs []string) (*Collector, error) {
+	c := &Collector{
+		out:     make(chan *decodedDatagram, 64),
+		errCh:   make(chan error, 1),
+		lastSeq: map[uint32]uint64{},
+		closeCh: make(chan struct{}),
+	}
+
+	for _, addr := range addrs {
+		laddr, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		conn, err := net.ListenUDP("udp", laddr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.conns = append(c.conns, conn)
+		go c.listen(conn)
+	}
+
+	return c, nil
+}
+
+func (c *Collector) listen(conn *net.UDPConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			select {
+			case c.errCh <- err:
+			default:
+			}
+			return
+		}
+
+		d, err := safeDecodeDatagram(buf[:n])
+		if err != nil {
+			// A single corrupt datagram shouldn't take down the stream.
+			continue
+		}
+		if !c.accept(d) {
+			continue
+		}
+
+		select {
+		case c.out <- d:
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// accept reports whether d is new, deduplicating by sniffer ID + sequence
+// number. Sequence numbers are expected to be monotonically increasing
+// per sniffer ID; anything at or below the last seen value is a duplicate
+// or a stale retransmit and is dropped.
+func (c *Collector) accept(d *decodedDatagram) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.lastSeq[d.snifferID]
+	if ok && d.seq <= last {
+		return false
+	}
+	c.lastSeq[d.snifferID] = d.seq
+	return true
+}
+
+// SupportedRates is unknown for a merged stream and always returns nil.
+func (c *Collector) SupportedRates() []gofi.DataRate { return nil }
+
+// SupportedChannels is unknown for a merged stream and always returns nil.
+func (c *Collector) SupportedChannels() []gofi.Channel { return nil }
+
+// Channel returns the zero Channel, since a Collector merges sniffers
+// that may each be tuned to a different channel. The per-frame channel
+// carried in each datagram is not exposed by Receive.
+func (c *Collector) Channel() gofi.Channel { return gofi.Channel{} }
+
+// SetChannel always returns ErrNotSupported.
+func (c *Collector) SetChannel(gofi.Channel) error { return ErrNotSupported }
+
+// Receive returns the next frame from any of the merged Forwarders.
+func (c *Collector) Receive() (gofi.Frame, *gofi.RadioInfo, error) {
+	select {
+	case d := <-c.out:
+		return d.frame, d.info, nil
+	case err := <-c.errCh:
+		return gofi.Frame{}, nil, err
+	case <-c.closeCh:
+		return gofi.Frame{}, nil, net.ErrClosed
+	}
+}
+
+// Send always returns ErrNotSupported; a Collector has no device to
+// transmit on.
+func (c *Collector) Send(gofi.Frame, gofi.TXParams) error { return ErrNotSupported }
+
+// Close closes every UDP listener and terminates pending Receive calls.
+func (c *Collector) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		for _, conn := range c.conns {
+			conn.Close()
+		}
+	})
+}
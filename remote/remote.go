@@ -0,0 +1,275 @@
+// Package remote lets several cheap capture nodes feed one collector over
+// UDP, so callers can consume many gofi.Handle instances spread across
+// machines as if they were a single device.
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+// ErrNotSupported is returned by Collector methods that have no meaning
+// for a merged, receive-only virtual Handle.
+var ErrNotSupported = errors.New("remote: not supported by a Collector")
+
+// A Forwarder wraps a local Handle and streams every frame it receives to
+// a Collector as length-prefixed UDP datagrams.
+type Forwarder struct {
+	handle    gofi.Handle
+	snifferID uint32
+	conn      *net.UDPConn
+	seq       uint64
+}
+
+// NewForwarder dials addr (host:port) and returns a Forwarder that will
+// tag every datagram it sends with snifferID, so a Collector merging
+// several Forwarders can tell them apart.
+func NewForwarder(handle gofi.Handle, snifferID uint32, addr string) (*Forwarder, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	return &Forwarder{handle: handle, snifferID: snifferID, conn: conn}, nil
+}
+
+// Run reads frames from the wrapped Handle and forwards them until
+// Receive or the UDP write returns an error, which Run then returns. Callers typically run
+// this in its own goroutine and stop it by closing the wrapped Handle.
+func (f *Forwarder) Run() error {
+	for {
+		frame, info, err := f.handle.Receive()
+		if err != nil {
+			return err
+		}
+		seq := atomic.AddUint64(&f.seq, 1) - 1
+		datagram := encodeDatagram(f.snifferID, seq, f.handle.Channel(), time.Now(), frame, info)
+		if _, err := f.conn.Write(datagram); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the underlying UDP socket.
+func (f *Forwarder) Close() error {
+	return f.conn.Close()
+}
+
+// encodeDatagram serializes one forwarded frame. All integers are
+// little-endian.
+//
+//	uint32  sniffer ID
+//	uint64  sequence number
+//	uint8   channel number
+//	uint8   channel width
+//	int64   unix nanosecond timestamp
+//	uint8   1 if RadioInfo follows, else 0
+//	  int32   frequency
+//	  int16   noise power
+//	  int16   signal power
+//	  int16   transmit power
+//	  uint16  rate
+//	  uint8   1 if MCS follows, else 0
+//	    [3]byte MCS
+//	  uint8   flags
+//	  uint8   1 if bad FCS, else 0
+//	uint32  frame length
+//	[]byte  frame bytes
+func encodeDatagram(snifferID uint32, seq uint64, ch gofi.Channel, t time.Time, f gofi.Frame, info *gofi.RadioInfo) []byte {
+	frame := f.Encode()
+
+	infoLen := 1
+	if info != nil {
+		infoLen = 16
+		if info.MCS != nil {
+			infoLen += 3
+		}
+	}
+
+	buf := make([]byte, 4+8+1+1+8+infoLen+4+len(frame))
+	off := 0
+	binary.LittleEndian.PutUint32(buf[off:], snifferID)
+	off += 4
+	binary.LittleEndian.PutUint64(buf[off:], seq)
+	off += 8
+	buf[off] = uint8(ch.Number)
+	off++
+	buf[off] = uint8(ch.Width)
+	off++
+	binary.LittleEndian.PutUint64(buf[off:], uint64(t.UnixNano()))
+	off += 8
+
+	if info == nil {
+		buf[off] = 0
+		off++
+	} else {
+		buf[off] = 1
+		off++
+		binary.LittleEndian.PutUint32(buf[off:], uint32(int32(info.Frequency)))
+		off += 4
+		binary.LittleEndian.PutUint16(buf[off:], uint16(int16(info.NoisePower)))
+		off += 2
+		binary.LittleEndian.PutUint16(buf[off:], uint16(int16(info.SignalPower)))
+		off += 2
+		binary.LittleEndian.PutUint16(buf[off:], uint16(int16(info.TransmitPower)))
+		off += 2
+		binary.LittleEndian.PutUint16(buf[off:], uint16(info.Rate))
+		off += 2
+		if info.MCS != nil {
+			buf[off] = 1
+			off++
+			enc := encodeMCS(*info.MCS)
+			copy(buf[off:], enc[:])
+			off += 3
+		} else {
+			buf[off] = 0
+			off++
+		}
+		buf[off] = uint8(info.Flags)
+		off++
+		if info.BadFCS {
+			buf[off] = 1
+		}
+		off++
+	}
+
+	binary.LittleEndian.PutUint32(buf[off:], uint32(len(frame)))
+	off += 4
+	copy(buf[off:], frame)
+
+	return buf
+}
+
+// encodeMCS and decodeMCS mirror gofi's own radiotap MCS wire format
+// (known/flags/mcs octets), since gofi.MCS does not export an encoder.
+func encodeMCS(m gofi.MCS) [3]byte {
+	known := uint8(0x01 | 0x04 | 0x10 | 0x20)
+	var flags uint8
+	if m.Bandwidth40MHz {
+		flags |= 0x01
+	}
+	if m.ShortGI {
+		flags |= 0x04
+	}
+	if m.FECLDPC {
+		flags |= 0x10
+	}
+	flags |= (m.STBCStreams & 0x3) << 5
+	return [3]byte{known, flags, m.Index}
+}
+
+func decodeMCS(b [3]byte) gofi.MCS {
+	known, flags, index := b[0], b[1], b[2]
+	m := gofi.MCS{Index: index}
+	if known&0x01 != 0 {
+		m.Bandwidth40MHz = flags&0x03 != 0
+	}
+	if known&0x04 != 0 {
+		m.ShortGI = flags&0x04 != 0
+	}
+	if known&0x10 != 0 {
+		m.FECLDPC = flags&0x10 != 0
+	}
+	if known&0x20 != 0 {
+		m.STBCStreams = (flags >> 5) & 0x3
+	}
+	return m
+}
+
+// decodedDatagram is a parsed UDP datagram, ready for dedup and merging.
+type decodedDatagram struct {
+	snifferID uint32
+	seq       uint64
+	channel   gofi.Channel
+	timestamp time.Time
+	frame     gofi.Frame
+	info      *gofi.RadioInfo
+}
+
+// safeDecodeDatagram wraps decodeDatagram with a recover, since it parses
+// datagrams from the network: a malformed or adversarial payload should
+// never be able to take down the Collector's listen goroutine.
+func safeDecodeDatagram(buf []byte) (d *decodedDatagram, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, err = nil, gofi.ErrFrameTooShort
+		}
+	}()
+	return decodeDatagram(buf)
+}
+
+func decodeDatagram(buf []byte) (*decodedDatagram, error) {
+	if len(buf) < 23 {
+		return nil, gofi.ErrFrameTooShort
+	}
+	off := 0
+	d := &decodedDatagram{}
+	d.snifferID = binary.LittleEndian.Uint32(buf[off:])
+	off += 4
+	d.seq = binary.LittleEndian.Uint64(buf[off:])
+	off += 8
+	d.channel = gofi.Channel{Number: int(buf[off]), Width: gofi.ChannelWidth(buf[off+1])}
+	off += 2
+	d.timestamp = time.Unix(0, int64(binary.LittleEndian.Uint64(buf[off:]))).UTC()
+	off += 8
+
+	hasInfo := buf[off]
+	off++
+	if hasInfo != 0 {
+		if len(buf)-off < 16 {
+			return nil, gofi.ErrFrameTooShort
+		}
+		info := &gofi.RadioInfo{}
+		info.Frequency = int(int32(binary.LittleEndian.Uint32(buf[off:])))
+		off += 4
+		info.NoisePower = int(int16(binary.LittleEndian.Uint16(buf[off:])))
+		off += 2
+		info.SignalPower = int(int16(binary.LittleEndian.Uint16(buf[off:])))
+		off += 2
+		info.TransmitPower = int(int16(binary.LittleEndian.Uint16(buf[off:])))
+		off += 2
+		info.Rate = gofi.DataRate(binary.LittleEndian.Uint16(buf[off:]))
+		off += 2
+		hasMCS := buf[off]
+		off++
+		if hasMCS != 0 {
+			if len(buf)-off < 3 {
+				return nil, gofi.ErrFrameTooShort
+			}
+			mcs := decodeMCS([3]byte{buf[off], buf[off+1], buf[off+2]})
+			info.MCS = &mcs
+			off += 3
+		}
+		if len(buf)-off < 2 {
+			return nil, gofi.ErrFrameTooShort
+		}
+		info.Flags = gofi.RadiotapFlags(buf[off])
+		off++
+		info.BadFCS = buf[off] != 0
+		off++
+		d.info = info
+	}
+
+	if len(buf)-off < 4 {
+		return nil, gofi.ErrFrameTooShort
+	}
+	frameLen := binary.LittleEndian.Uint32(buf[off:])
+	off += 4
+	if uint32(len(buf)-off) < frameLen {
+		return nil, gofi.ErrFrameTooShort
+	}
+	f, err := gofi.ParseFrame(buf[off:off+int(frameLen)], false)
+	if err != nil {
+		return nil, err
+	}
+	d.frame = *f
+	return d, nil
+}
@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5l1v3r1/gofi"
+)
+
+func TestDecodeDatagramRoundTrip(t *testing.T) {
+	frame := gofi.Frame{
+		FrameControl: gofi.FrameControl{Type: gofi.FrameTypeControl, Subtype: gofi.SubtypeACK},
+	}
+	now := time.Unix(1700000000, 0).UTC()
+	buf := encodeDatagram(7, 42, gofi.Channel{Number: 6}, now, frame, nil)
+
+	d, err := decodeDatagram(buf)
+	if err != nil {
+		t.Fatalf("decodeDatagram: %v", err)
+	}
+	if d.snifferID != 7 || d.seq != 42 {
+		t.Fatalf("snifferID/seq = %d/%d, want 7/42", d.snifferID, d.seq)
+	}
+	if d.channel.Number != 6 {
+		t.Fatalf("channel = %d, want 6", d.channel.Number)
+	}
+}
+
+func TestDecodeDatagramShortFixedHeader(t *testing.T) {
+	// The fixed header (snifferID 4 + seq 8 + channel 2 + timestamp 8 +
+	// hasInfo 1) is 23 bytes; anything shorter must be rejected before
+	// hasInfo is ever read, not panic.
+	for n := 0; n < 23; n++ {
+		if _, err := decodeDatagram(make([]byte, n)); err != gofi.ErrFrameTooShort {
+			t.Fatalf("len %d: err = %v, want ErrFrameTooShort", n, err)
+		}
+	}
+}
+
+func TestDecodeDatagramGarbageDoesNotPanic(t *testing.T) {
+	for n := 0; n < 64; n++ {
+		buf := make([]byte, n)
+		for i := range buf {
+			buf[i] = 0xff
+		}
+		if _, err := safeDecodeDatagram(buf); err != nil {
+			continue
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package gofi
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// scannerEWMAAlpha weights how much a new signal/noise sample contributes
+// to a BSS's smoothed reading; higher favors recent samples.
+const scannerEWMAAlpha = 0.3
+
+// Security identifies the authentication and encryption scheme a BSS
+// advertises, derived from its RSN/WPA Information Elements.
+type Security int
+
+// Security levels a Scanner can classify a BSS as.
+const (
+	SecurityOpen Security = iota
+	SecurityWEP
+	SecurityWPA
+	SecurityWPA2
+	SecurityWPA3
+)
+
+// String returns a short human-readable name for the security level.
+func (s Security) String() string {
+	switch s {
+	case SecurityOpen:
+		return "open"
+	case SecurityWEP:
+		return "WEP"
+	case SecurityWPA:
+		return "WPA"
+	case SecurityWPA2:
+		return "WPA2"
+	case SecurityWPA3:
+		return "WPA3"
+	default:
+		return "unknown"
+	}
+}
+
+// A BSS is an aggregated record of everything a Scanner has observed
+// about a single basic service set.
+type BSS struct {
+	BSSID          net.HardwareAddr
+	SSID           string
+	Channel        int
+	BeaconInterval uint16
+	Capabilities   CapabilityInfo
+	Security       Security
+	SupportedRates []DataRate
+	HTSupported    bool
+	VHTSupported   bool
+
+	// SignalPower and NoisePower are EWMA-smoothed dBm readings taken
+	// from the RadioInfo of the most recently seen frames.
+	SignalPower float64
+	NoisePower  float64
+
+	BeaconCount int
+
+	// BSSLoad is non-nil once the AP has advertised a BSS Load element.
+	BSSLoad *BSSLoad
+
+	LastSeen time.Time
+}
+
+// A BSSUpdate reports that BSS changed because of a newly observed frame.
+type BSSUpdate struct {
+	BSS BSS
+}
+
+// A Scanner passively discovers nearby BSSes by hopping channels with a
+// Hopper and decoding Beacon and Probe Response frames.
+type Scanner struct {
+	hopper *Hopper
+
+	mu  sync.Mutex
+	bss map[string]*BSS
+
+	subsMu sync.Mutex
+	subs   []chan BSSUpdate
+}
+
+// NewScanner creates a Scanner that scans handle, hopping channels
+// according to policy.
+func NewScanner(handle Handle, policy HopPolicy) *Scanner {
+	return WrapHopper(NewHopper(handle, policy))
+}
+
+// WrapHopper creates a Scanner that scans using an already-running
+// Hopper, for callers that also need direct control of it (e.g. to Pause
+// for a targeted exchange between scans).
+func WrapHopper(hopper *Hopper) *Scanner {
+	s := &Scanner{hopper: hopper, bss: map[string]*BSS{}}
+	go s.run()
+	return s
+}
+
+func (s *Scanner) run() {
+	for {
+		f, info, err := s.hopper.Receive()
+		if err != nil {
+			return
+		}
+		s.observe(f, info)
+	}
+}
+
+func (s *Scanner) observe(f Frame, info *RadioInfo) {
+	if f.FrameControl.Type != FrameTypeManagement {
+		return
+	}
+
+	var ies []IE
+	var interval uint16
+	var caps CapabilityInfo
+	var isBeacon bool
+
+	switch f.FrameControl.Subtype {
+	case SubtypeBeacon:
+		b, err := ParseBeacon(f.Body)
+		if err != nil {
+			return
+		}
+		ies, interval, caps, isBeacon = b.IEs, b.Interval, b.CapabilityInfo, true
+	case SubtypeProbeResponse:
+		pr, err := ParseProbeResponse(f.Body)
+		if err != nil {
+			return
+		}
+		ies, interval, caps = pr.IEs, pr.Interval, pr.CapabilityInfo
+	default:
+		return
+	}
+
+	if len(f.Addr3) != 6 {
+		return
+	}
+	key := f.Addr3.String()
+
+	s.mu.Lock()
+	rec, ok := s.bss[key]
+	if !ok {
+		rec = &BSS{BSSID: append(net.HardwareAddr{}, f.Addr3...)}
+		s.bss[key] = rec
+	}
+	first := !ok
+	rec.BeaconInterval = interval
+	rec.Capabilities = caps
+	rec.LastSeen = time.Now()
+	if isBeacon {
+		rec.BeaconCount++
+	}
+	if caps.Privacy() {
+		rec.Security = SecurityWEP
+	} else {
+		rec.Security = SecurityOpen
+	}
+
+	var dsChannel, htChannel int
+	for _, ie := range ies {
+		switch ie.ID {
+		case IESSID:
+			if ssid := ie.SSID(); ssid != "" {
+				rec.SSID = ssid
+			}
+		case IEDSParameterSet:
+			if ch, ok := ie.Channel(); ok {
+				dsChannel = ch
+			}
+		case IEHTOperation:
+			if op, ok := ie.HTOperation(); ok {
+				htChannel = op.PrimaryChannel
+			}
+		case IESupportedRates:
+			rec.SupportedRates = append([]DataRate{}, ie.SupportedRates()...)
+		case IEExtSupportedRates:
+			rec.SupportedRates = append(rec.SupportedRates, ie.ExtSupportedRates()...)
+		case IEHTCapabilities:
+			rec.HTSupported = true
+		case IEVHTCapabilities:
+			rec.VHTSupported = true
+		case IEBSSLoad:
+			if bl, ok := ie.BSSLoad(); ok {
+				rec.BSSLoad = bl
+			}
+		case IERSN:
+			if rsn, ok := ie.RSN(); ok {
+				rec.Security = securityFromRSN(rsn)
+			}
+		case IEVendorSpecific:
+			if _, ok := ie.WPA(); ok && rec.Security != SecurityWPA2 && rec.Security != SecurityWPA3 {
+				rec.Security = SecurityWPA
+			}
+		}
+	}
+
+	// DS Parameter Set is the authoritative source when present (it's
+	// mandatory on 2.4GHz beacons); fall back to HT Operation's primary
+	// channel, since 5GHz/HT-only beacons usually omit DS Parameter Set.
+	switch {
+	case dsChannel != 0:
+		rec.Channel = dsChannel
+	case htChannel != 0:
+		rec.Channel = htChannel
+	}
+
+	if info != nil {
+		rec.SignalPower = ewma(rec.SignalPower, float64(info.SignalPower), first)
+		rec.NoisePower = ewma(rec.NoisePower, float64(info.NoisePower), first)
+	}
+
+	snapshot := *rec
+	s.mu.Unlock()
+
+	s.notify(snapshot)
+}
+
+func securityFromRSN(rsn *RSN) Security {
+	for _, akm := range rsn.AKMSuites {
+		if akm == AKMSuiteSAE || akm == AKMSuite8021XSuiteB192 {
+			return SecurityWPA3
+		}
+	}
+	return SecurityWPA2
+}
+
+func ewma(prev, sample float64, first bool) float64 {
+	if first {
+		return sample
+	}
+	return scannerEWMAAlpha*sample + (1-scannerEWMAAlpha)*prev
+}
+
+func (s *Scanner) notify(bss BSS) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for _, ch := range s.subs {
+		select {
+		case ch <- BSSUpdate{BSS: bss}:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives an update every time a BSS is
+// discovered or changes. The channel is buffered; updates are dropped
+// rather than blocking the Scanner if the subscriber falls behind.
+func (s *Scanner) Subscribe() <-chan BSSUpdate {
+	ch := make(chan BSSUpdate, 32)
+	s.subsMu.Lock()
+	s.subs = append(s.subs, ch)
+	s.subsMu.Unlock()
+	return ch
+}
+
+// Scan waits for duration and returns every BSS observed so far,
+// including any observed before Scan was called. If ctx is canceled
+// first, Scan returns no BSSes and ctx.Err().
+func (s *Scanner) Scan(ctx context.Context, duration time.Duration) ([]BSS, error) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+	return s.Snapshot(), nil
+}
+
+// Snapshot returns every BSS the Scanner has observed so far.
+func (s *Scanner) Snapshot() []BSS {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]BSS, 0, len(s.bss))
+	for _, rec := range s.bss {
+		out = append(out, *rec)
+	}
+	return out
+}
+
+// Close stops the Scanner's Hopper. It does not close the wrapped Handle.
+func (s *Scanner) Close() {
+	s.hopper.Close()
+}
@@ -0,0 +1,79 @@
+package gofi
+
+import (
+	"net"
+	"testing"
+)
+
+func encodeIE(id uint8, data []byte) []byte {
+	return append([]byte{id, byte(len(data))}, data...)
+}
+
+func beaconBody(ies ...[]byte) []byte {
+	body := make([]byte, 12) // timestamp(8) + interval(2) + capability info(2)
+	for _, ie := range ies {
+		body = append(body, ie...)
+	}
+	return body
+}
+
+func beaconFrame(bssid net.HardwareAddr, body []byte) Frame {
+	return Frame{
+		FrameControl: FrameControl{Type: FrameTypeManagement, Subtype: SubtypeBeacon},
+		Addr3:        bssid,
+		Body:         body,
+	}
+}
+
+func TestScannerChannelFromDSParameterSet(t *testing.T) {
+	s := &Scanner{bss: map[string]*BSS{}}
+	bssid := mustAddr("00:11:22:33:44:55")
+	body := beaconBody(encodeIE(IEDSParameterSet, []byte{6}))
+
+	s.observe(beaconFrame(bssid, body), nil)
+
+	rec := s.bss[bssid.String()]
+	if rec == nil {
+		t.Fatalf("no BSS record created")
+	}
+	if rec.Channel != 6 {
+		t.Fatalf("Channel = %d, want 6", rec.Channel)
+	}
+}
+
+func TestScannerChannelFallsBackToHTOperation(t *testing.T) {
+	s := &Scanner{bss: map[string]*BSS{}}
+	bssid := mustAddr("00:11:22:33:44:55")
+	htOp := append([]byte{149}, make([]byte, 21)...) // primary channel + rest of the element
+	body := beaconBody(encodeIE(IEHTOperation, htOp))
+
+	s.observe(beaconFrame(bssid, body), nil)
+
+	rec := s.bss[bssid.String()]
+	if rec == nil {
+		t.Fatalf("no BSS record created")
+	}
+	if rec.Channel != 149 {
+		t.Fatalf("Channel = %d, want 149 (from HT Operation, since DS Parameter Set is absent)", rec.Channel)
+	}
+}
+
+func TestScannerDSParameterSetTakesPrecedenceOverHTOperation(t *testing.T) {
+	s := &Scanner{bss: map[string]*BSS{}}
+	bssid := mustAddr("00:11:22:33:44:55")
+	htOp := append([]byte{149}, make([]byte, 21)...)
+	body := beaconBody(
+		encodeIE(IEHTOperation, htOp),
+		encodeIE(IEDSParameterSet, []byte{6}),
+	)
+
+	s.observe(beaconFrame(bssid, body), nil)
+
+	rec := s.bss[bssid.String()]
+	if rec == nil {
+		t.Fatalf("no BSS record created")
+	}
+	if rec.Channel != 6 {
+		t.Fatalf("Channel = %d, want 6 (DS Parameter Set must win over HT Operation)", rec.Channel)
+	}
+}